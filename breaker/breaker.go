@@ -0,0 +1,275 @@
+// Package breaker provides a circuit breaker that trips on errors selected by
+// their [errclass.Class]. Calls made through a [Breaker] are guarded by
+// [calm.Unpanic]. Once enough consecutive calls fail with a counted class, the
+// breaker opens and calls fail fast with an error wrapping [ErrOpen], which is
+// classified [errclass.Transient]. After a timeout the breaker lets a limited
+// number of probe calls through, and closes again once they succeed.
+package breaker
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/calm"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenProbes   = 1
+)
+
+// ErrOpen is returned, wrapped with the breaker's state as [errcontext] attrs,
+// by [Breaker.Do] when the breaker is open or has no probe slot free. It is
+// classified [errclass.Transient], so [github.com/wood-jp/xerrors/retry.Do] will retry it.
+var ErrOpen = xerrors.NewSentinel("circuit breaker is open", xerrors.With(errclass.Transient))
+
+// State is the state of a [Breaker].
+type State int
+
+const (
+	// Closed lets every call through, counting consecutive failures.
+	Closed State = iota
+	// Open fails every call with [ErrOpen] until the open timeout has passed.
+	Open
+	// HalfOpen lets a limited number of probe calls through to decide whether
+	// to close or open again.
+	HalfOpen
+)
+
+// String returns the lowercase name of the State.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a circuit breaker. Create one with [New].
+// A Breaker is safe for concurrent use.
+type Breaker struct {
+	name             string
+	classes          []errclass.Class
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenProbes   int
+	now              func() time.Time
+	onStateChange    func(from, to State)
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+	// generation is incremented on every change of state, so that the outcome
+	// of a call that started in an earlier state is ignored.
+	generation uint64
+}
+
+// Option configures a [Breaker] created by [New].
+type Option func(b *Breaker)
+
+// WithClasses sets the classes of error that count as failures. An error counts
+// if its class, or the severity of its class, is one of classes. Other errors,
+// such as validation failures, are returned to the caller but neither count as
+// failures nor reset the count. The default is [errclass.Transient].
+func WithClasses(classes ...errclass.Class) Option {
+	return func(b *Breaker) {
+		b.classes = classes
+	}
+}
+
+// WithFailureThreshold sets the number of consecutive counted failures that
+// opens the breaker. The default is 5.
+func WithFailureThreshold(n int) Option {
+	return func(b *Breaker) {
+		b.failureThreshold = n
+	}
+}
+
+// WithOpenTimeout sets how long the breaker stays open before letting probe
+// calls through. The default is 30s.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.openTimeout = d
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls let through at a time while
+// half-open, which is also the number of successful probes needed to close the
+// breaker. The default is 1.
+func WithHalfOpenProbes(n int) Option {
+	return func(b *Breaker) {
+		b.halfOpenProbes = n
+	}
+}
+
+// WithStateChange sets a function called on every change of state, for example
+// to log it or update a metric. It is called with the breaker's lock held, so it
+// must not call the breaker.
+func WithStateChange(f func(from, to State)) Option {
+	return func(b *Breaker) {
+		b.onStateChange = f
+	}
+}
+
+// WithClock sets the function used to tell the time. The default is [time.Now].
+func WithClock(now func() time.Time) Option {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// New returns a closed [Breaker]. The name is added to the context of the errors
+// it returns, to tell breakers apart in logs.
+func New(name string, opts ...Option) *Breaker {
+	b := &Breaker{
+		name:             name,
+		classes:          []errclass.Class{errclass.Transient},
+		failureThreshold: defaultFailureThreshold,
+		openTimeout:      defaultOpenTimeout,
+		halfOpenProbes:   defaultHalfOpenProbes,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.failureThreshold = max(b.failureThreshold, 1)
+	b.halfOpenProbes = max(b.halfOpenProbes, 1)
+	return b
+}
+
+// Do calls f if the breaker allows it, and returns its error. Panics inside f are
+// recovered by [calm.Unpanic]; they count as failures only if [errclass.Panic]
+// is one of the counted classes.
+//
+// If the breaker is open, or half-open with every probe slot taken, f is not
+// called and Do returns an error wrapping [ErrOpen], with the breaker's name,
+// state, consecutive failure count and the time left until it lets probes
+// through attached as [errcontext] attrs.
+func (b *Breaker) Do(f func() error) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = calm.Unpanic(f)
+	b.record(generation, err)
+	return err
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+	return b.state
+}
+
+// allow reports whether a call may be made, returning the generation it is made
+// in, or the error to return instead.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+	switch {
+	case b.state == Open:
+		return 0, b.openError()
+	case b.state == HalfOpen && b.inFlight >= b.halfOpenProbes:
+		return 0, b.openError()
+	case b.state == HalfOpen:
+		b.inFlight++
+	}
+	return b.generation, nil
+}
+
+// record updates the breaker with the outcome of a call made in generation.
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	counted := err != nil && b.counts(err)
+	switch b.state {
+	case Closed:
+		switch {
+		case counted:
+			b.failures++
+			if b.failures >= b.failureThreshold {
+				b.setState(Open)
+			}
+		case err == nil:
+			b.failures = 0
+		}
+	case HalfOpen:
+		b.inFlight--
+		switch {
+		case counted:
+			b.failures++
+			b.setState(Open)
+		case err == nil:
+			b.successes++
+			if b.successes >= b.halfOpenProbes {
+				b.failures = 0
+				b.setState(Closed)
+			}
+		}
+	case Open:
+		// Unreachable: calls are not made while open, and the generation
+		// changes when the breaker opens.
+	}
+}
+
+// counts reports whether err counts as a failure.
+func (b *Breaker) counts(err error) bool {
+	class := errclass.GetClass(err)
+	return slices.Contains(b.classes, class) || slices.Contains(b.classes, class.Severity())
+}
+
+// expire moves an open breaker whose timeout has passed to half-open.
+// b.mu must be held.
+func (b *Breaker) expire() {
+	if b.state == Open && !b.now().Before(b.openedAt.Add(b.openTimeout)) {
+		b.setState(HalfOpen)
+	}
+}
+
+// setState moves the breaker to state, starting a new generation.
+// b.mu must be held.
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.inFlight = 0
+	b.successes = 0
+	if state == Open {
+		b.openedAt = b.now()
+	}
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
+
+// openError returns [ErrOpen] with the breaker's state as context.
+// b.mu must be held.
+func (b *Breaker) openError() error {
+	retryAfter := max(b.openedAt.Add(b.openTimeout).Sub(b.now()), 0)
+	return errcontext.Add(ErrOpen,
+		slog.String("breaker", b.name),
+		slog.String("state", b.state.String()),
+		slog.Int("failures", b.failures),
+		slog.Duration("retry_after", retryAfter),
+	)
+}
@@ -0,0 +1,298 @@
+package breaker_test
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors/breaker"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+)
+
+var (
+	errTest       = errors.New("this is a test error")
+	errTransient  = errclass.WrapAs(errTest, errclass.Transient)
+	errPersistent = errclass.WrapAs(errTest, errclass.Persistent)
+)
+
+// clock is a manually advanced clock for [breaker.WithClock].
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func fail(err error) func() error {
+	return func() error { return err }
+}
+
+func succeed() error {
+	return nil
+}
+
+// trip opens b with n counted failures.
+func trip(t *testing.T, b *breaker.Breaker, n int) {
+	t.Helper()
+	for range n {
+		if err := b.Do(fail(errTransient)); !errors.Is(err, errTest) {
+			t.Fatalf("Do() = %v, want %v", err, errTest)
+		}
+	}
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("State() = %s, want open", got)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	var transitions [][2]breaker.State
+	b := breaker.New("test",
+		breaker.WithClock(c.Now),
+		breaker.WithFailureThreshold(3),
+		breaker.WithOpenTimeout(10*time.Second),
+		breaker.WithStateChange(func(from, to breaker.State) {
+			transitions = append(transitions, [2]breaker.State{from, to})
+		}),
+	)
+
+	// A success resets the count.
+	_ = b.Do(fail(errTransient))
+	_ = b.Do(fail(errTransient))
+	_ = b.Do(succeed)
+	_ = b.Do(fail(errTransient))
+	_ = b.Do(fail(errTransient))
+	if got := b.State(); got != breaker.Closed {
+		t.Fatalf("State() = %s, want closed", got)
+	}
+	trip(t, b, 1)
+
+	called := false
+	err := b.Do(func() error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Fatal("f called while open")
+	}
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("Do() = %v, want %v", err, breaker.ErrOpen)
+	}
+
+	// A failed probe opens the breaker again.
+	c.Advance(10 * time.Second)
+	if got := b.State(); got != breaker.HalfOpen {
+		t.Fatalf("State() = %s, want half-open", got)
+	}
+	if err := b.Do(fail(errTransient)); !errors.Is(err, errTest) {
+		t.Fatalf("Do() = %v, want %v", err, errTest)
+	}
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("State() = %s, want open", got)
+	}
+
+	// A successful probe closes it.
+	c.Advance(10 * time.Second)
+	if err := b.Do(succeed); err != nil {
+		t.Fatalf("Do() = %v, want nil", err)
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Fatalf("State() = %s, want closed", got)
+	}
+
+	want := [][2]breaker.State{
+		{breaker.Closed, breaker.Open},
+		{breaker.Open, breaker.HalfOpen},
+		{breaker.HalfOpen, breaker.Open},
+		{breaker.Open, breaker.HalfOpen},
+		{breaker.HalfOpen, breaker.Closed},
+	}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	b := breaker.New("payments", breaker.WithClock(c.Now), breaker.WithFailureThreshold(2))
+	trip(t, b, 2)
+	c.Advance(10 * time.Second)
+
+	err := b.Do(succeed)
+	if got := errclass.GetClass(err); got != errclass.Transient {
+		t.Errorf("GetClass() = %s, want transient", got)
+	}
+	want := errcontext.Context{
+		"breaker":     slog.StringValue("payments"),
+		"state":       slog.StringValue("open"),
+		"failures":    slog.IntValue(2),
+		"retry_after": slog.DurationValue(20 * time.Second),
+	}
+	if got := errcontext.Get(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []breaker.Option
+		err  error
+		trip bool
+	}{
+		{"transient", nil, errTransient, true},
+		{"persistent", nil, errPersistent, false},
+		{"unknown", nil, errTest, false},
+		{"panic", nil, errclass.WrapAs(errTest, errclass.Panic), false},
+		{"persistent counted", []breaker.Option{breaker.WithClasses(errclass.Persistent)}, errPersistent, true},
+		{"transient not counted", []breaker.Option{breaker.WithClasses(errclass.Persistent)}, errTransient, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := breaker.New("test", append(tt.opts, breaker.WithFailureThreshold(2))...)
+			_ = b.Do(fail(tt.err))
+			_ = b.Do(fail(tt.err))
+			if got := b.State() == breaker.Open; got != tt.trip {
+				t.Errorf("open = %v, want %v", got, tt.trip)
+			}
+		})
+	}
+}
+
+func TestIgnoredErrorsKeepCount(t *testing.T) {
+	t.Parallel()
+
+	b := breaker.New("test", breaker.WithFailureThreshold(2))
+	_ = b.Do(fail(errTransient))
+	_ = b.Do(fail(errPersistent))
+	_ = b.Do(fail(errTransient))
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("State() = %s, want open", got)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	t.Parallel()
+
+	b := breaker.New("test", breaker.WithClasses(errclass.Panic), breaker.WithFailureThreshold(1))
+	err := b.Do(func() error {
+		panic("boom")
+	})
+	if got := errclass.GetClass(err); got != errclass.Panic {
+		t.Fatalf("GetClass() = %s, want panic", got)
+	}
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("State() = %s, want open", got)
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	b := breaker.New("test",
+		breaker.WithClock(c.Now),
+		breaker.WithFailureThreshold(1),
+		breaker.WithOpenTimeout(time.Second),
+		breaker.WithHalfOpenProbes(2),
+	)
+	trip(t, b, 1)
+	c.Advance(time.Second)
+
+	// Two probes may be in flight at once; a third is rejected.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			_ = b.Do(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		})
+	}
+	<-started
+	<-started
+	err := b.Do(succeed)
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("Do() = %v, want %v", err, breaker.ErrOpen)
+	}
+	if got := errcontext.Get(err)["state"].String(); got != "half-open" {
+		t.Fatalf("state = %s, want half-open", got)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := b.State(); got != breaker.Closed {
+		t.Fatalf("State() = %s, want closed", got)
+	}
+}
+
+func TestStaleOutcome(t *testing.T) {
+	t.Parallel()
+
+	b := breaker.New("test", breaker.WithFailureThreshold(1))
+
+	// A call that started before the breaker opened does not close it.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = b.Do(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	trip(t, b, 1)
+	close(release)
+	<-done
+
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("State() = %s, want open", got)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[breaker.State]string{
+		breaker.Closed:   "closed",
+		breaker.Open:     "open",
+		breaker.HalfOpen: "half-open",
+		breaker.State(9): "unknown",
+	}
+	for state, want := range tests {
+		if got := state.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
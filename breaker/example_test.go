@@ -0,0 +1,39 @@
+package breaker_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/breaker"
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+func ExampleBreaker_Do() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			if a.Key == "retry_after" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	b := breaker.New("inventory", breaker.WithFailureThreshold(2), breaker.WithOpenTimeout(time.Minute))
+	unavailable := errclass.WrapAs(errors.New("service unavailable"), errclass.Transient)
+	for range 3 {
+		err := b.Do(func() error {
+			return unavailable
+		})
+		logger.Error("lookup failed", xerrors.Log(err))
+	}
+	// Output:
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"service unavailable","error_detail":{"class":"transient"}}}
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"service unavailable","error_detail":{"class":"transient"}}}
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"circuit breaker is open","error_detail":{"class":"transient","context":{"breaker":"inventory","failures":2,"state":"open"}}}}
+}
@@ -0,0 +1,359 @@
+// Package burst detects spikes in error rates. A [Detector] counts the errors it
+// observes by [errclass.Class] and [errcode.Code] over a rolling window, reports
+// the current rates with [Detector.Snapshot], and notifies a callback when the
+// number of matching errors crosses a [Threshold] and when it drops back below it.
+//
+// Errors are fed to a Detector with [Detector.Observe], [Detector.Log], from
+// every goroutine of a group with [Detector.Func], or from HTTP server responses
+// with [Detector.Middleware].
+package burst
+
+import (
+	"cmp"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+)
+
+const (
+	// defaultWindow is the default length of the rolling window.
+	defaultWindow = time.Minute
+	// buckets is the number of buckets the window is divided into. Errors leave
+	// the window one bucket at a time.
+	buckets = 10
+)
+
+// Key identifies a group of errors that are counted together.
+type Key struct {
+	Class errclass.Class
+	Code  errcode.Code
+}
+
+// Rate is the number of errors with a given [Key] in the window.
+type Rate struct {
+	Key
+	// Count is the number of errors in the window.
+	Count int
+	// PerSecond is Count divided by the length of the window.
+	PerSecond float64
+}
+
+// Snapshot describes the errors in the window at a point in time.
+type Snapshot struct {
+	// Time is the time the snapshot was taken.
+	Time time.Time
+	// Window is the length of the window.
+	Window time.Duration
+	// Total is the number of errors in the window.
+	Total int
+	// Rates holds the rate of each key with errors in the window, sorted by
+	// class and then code.
+	Rates []Rate
+	// Firing holds the names of the thresholds that are currently crossed,
+	// in the order they were given to [New].
+	Firing []string
+}
+
+// Threshold fires when the number of matching errors in the window reaches Limit.
+type Threshold struct {
+	// Name identifies the threshold in an [Alert] and [Snapshot].
+	Name string
+	// Match reports whether errors with the given key count towards the
+	// threshold. If nil, every error counts. See [MatchClass] and [MatchCode].
+	Match func(k Key) bool
+	// Limit is the number of matching errors in the window at which the
+	// threshold fires. Limits below 1 are treated as 1.
+	Limit int
+	// Notify is called when the threshold fires and when it resolves.
+	// It is called without any lock held, but must not block for long.
+	Notify func(a Alert)
+}
+
+// Alert describes a change of state of a [Threshold].
+type Alert struct {
+	// Threshold is the name of the threshold.
+	Threshold string
+	// Firing is true when the threshold has been reached, and false when the
+	// count has dropped back below it.
+	Firing bool
+	// Count is the number of matching errors in the window.
+	Count int
+	// Limit is the threshold's limit.
+	Limit int
+	// Window is the length of the window.
+	Window time.Duration
+	// Err is the error whose observation fired the threshold, or nil when resolved.
+	Err error
+}
+
+// LogValue implements [slog.LogValuer].
+func (a Alert) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("threshold", a.Threshold),
+		slog.Bool("firing", a.Firing),
+		slog.Int("count", a.Count),
+		slog.Int("limit", a.Limit),
+		slog.Duration("window", a.Window),
+	}
+	if a.Err != nil {
+		attrs = append(attrs, xerrors.Log(a.Err))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// MatchClass returns a [Threshold.Match] function that matches errors of any of
+// the given classes. Classes created with [errclass.Register] also match their
+// severity.
+func MatchClass(classes ...errclass.Class) func(k Key) bool {
+	return func(k Key) bool {
+		return slices.Contains(classes, k.Class) || slices.Contains(classes, k.Class.Severity())
+	}
+}
+
+// MatchCode returns a [Threshold.Match] function that matches errors with any
+// of the given codes.
+func MatchCode(codes ...errcode.Code) func(k Key) bool {
+	return func(k Key) bool {
+		return slices.Contains(codes, k.Code)
+	}
+}
+
+// bucket counts the errors observed during one slice of the window.
+type bucket struct {
+	// used is false for a bucket that has never held a slice.
+	used bool
+	// index is the number of the slice; see [Detector.index].
+	index   int64
+	counts  map[Key]int
+	matched []int
+}
+
+// threshold is the state of a [Threshold].
+type threshold struct {
+	Threshold
+	firing bool
+}
+
+// Detector counts errors over a rolling window.
+// A Detector is safe for concurrent use.
+type Detector struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	origin     time.Time
+	started    bool
+	buckets    [buckets]bucket
+	thresholds []threshold
+}
+
+// Option configures a [Detector] created by [New].
+type Option func(d *Detector)
+
+// WithWindow sets the length of the rolling window. The default is one minute.
+// Errors leave the window in steps of a tenth of its length.
+func WithWindow(window time.Duration) Option {
+	return func(d *Detector) {
+		d.window = window
+	}
+}
+
+// WithThreshold adds a threshold. It may be given more than once.
+func WithThreshold(t Threshold) Option {
+	return func(d *Detector) {
+		d.thresholds = append(d.thresholds, threshold{Threshold: t})
+	}
+}
+
+// WithClock sets the function used to tell the time. The default is [time.Now].
+func WithClock(now func() time.Time) Option {
+	return func(d *Detector) {
+		d.now = now
+	}
+}
+
+// New returns a [Detector].
+func New(opts ...Option) *Detector {
+	d := &Detector{
+		window: defaultWindow,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Observe counts err, and notifies any threshold that it causes to fire or that
+// has resolved since the last call to Observe or [Detector.Snapshot].
+// It does nothing if err is nil.
+func (d *Detector) Observe(err error) {
+	if err == nil {
+		return
+	}
+	key := Key{Class: errclass.GetClass(err), Code: errcode.Get(err)}
+
+	d.mu.Lock()
+	current := d.index(d.now())
+	b := d.bucket(current)
+	b.counts[key]++
+	for i, t := range d.thresholds {
+		if t.Match == nil || t.Match(key) {
+			b.matched[i]++
+		}
+	}
+	alerts := d.evaluate(current, err)
+	d.mu.Unlock()
+
+	notify(alerts)
+}
+
+// Log observes err and returns [xerrors.Log](err), so that errors are counted
+// exactly where they are logged:
+//
+//	logger.Error("request failed", detector.Log(err))
+func (d *Detector) Log(err error) slog.Attr {
+	d.Observe(err)
+	return xerrors.Log(err)
+}
+
+// Func returns a function that calls f and observes the error it returns, so that
+// every error of a group is counted, not only the one returned by Wait:
+//
+//	g.Go(detector.Func(task))
+func (d *Detector) Func(f func() error) func() error {
+	return func() error {
+		err := f()
+		d.Observe(err)
+		return err
+	}
+}
+
+// Snapshot returns the errors currently in the window, and notifies any
+// threshold that has resolved since the last call to [Detector.Observe] or Snapshot.
+func (d *Detector) Snapshot() Snapshot {
+	now := d.now()
+
+	d.mu.Lock()
+	current := d.index(now)
+	alerts := d.evaluate(current, nil)
+	counts := map[Key]int{}
+	total := 0
+	for _, b := range d.live(current) {
+		for key, n := range b.counts {
+			counts[key] += n
+			total += n
+		}
+	}
+	var firing []string
+	for _, t := range d.thresholds {
+		if t.firing {
+			firing = append(firing, t.Name)
+		}
+	}
+	d.mu.Unlock()
+
+	notify(alerts)
+
+	rates := make([]Rate, 0, len(counts))
+	for _, key := range slices.SortedFunc(maps.Keys(counts), compareKeys) {
+		rates = append(rates, Rate{
+			Key:       key,
+			Count:     counts[key],
+			PerSecond: float64(counts[key]) / d.window.Seconds(),
+		})
+	}
+	return Snapshot{Time: now, Window: d.window, Total: total, Rates: rates, Firing: firing}
+}
+
+// index returns the number of the slice of the window that t falls in, counted
+// from the first time seen by the detector, rounding down, so that times before
+// it give negative numbers. Counting from the Unix epoch would overflow for
+// times such as the zero Time. d.mu must be held.
+func (d *Detector) index(t time.Time) int64 {
+	if !d.started {
+		d.origin, d.started = t, true
+	}
+	width := max(int64(d.window/buckets), 1)
+	offset := int64(t.Sub(d.origin))
+	index := offset / width
+	if offset%width < 0 {
+		index--
+	}
+	return index
+}
+
+// bucket returns the bucket for index, resetting it if it holds another slice.
+// d.mu must be held.
+func (d *Detector) bucket(index int64) *bucket {
+	b := &d.buckets[slot(index)]
+	if !b.used || b.index != index {
+		*b = bucket{used: true, index: index, counts: map[Key]int{}, matched: make([]int, len(d.thresholds))}
+	}
+	return b
+}
+
+// slot returns the position in the ring of buckets of the slice with index.
+func slot(index int64) int64 {
+	return (index%buckets + buckets) % buckets
+}
+
+// live returns the buckets within the window ending with current.
+// d.mu must be held.
+func (d *Detector) live(current int64) []*bucket {
+	var live []*bucket
+	for i := range d.buckets {
+		b := &d.buckets[i]
+		if b.used && b.index > current-buckets && b.index <= current {
+			live = append(live, b)
+		}
+	}
+	return live
+}
+
+// evaluate updates the state of each threshold and returns the alerts for those
+// that changed, with err as the cause of any that fired. d.mu must be held.
+func (d *Detector) evaluate(current int64, err error) []func() {
+	live := d.live(current)
+	var alerts []func()
+	for i := range d.thresholds {
+		t := &d.thresholds[i]
+		count := 0
+		for _, b := range live {
+			count += b.matched[i]
+		}
+		firing := count >= max(t.Limit, 1)
+		if firing == t.firing {
+			continue
+		}
+		t.firing = firing
+		if t.Notify == nil {
+			continue
+		}
+		alert := Alert{Threshold: t.Name, Firing: firing, Count: count, Limit: t.Limit, Window: d.window}
+		if firing {
+			alert.Err = err
+		}
+		notify := t.Notify
+		alerts = append(alerts, func() { notify(alert) })
+	}
+	return alerts
+}
+
+// notify calls each of alerts in turn.
+func notify(alerts []func()) {
+	for _, alert := range alerts {
+		alert()
+	}
+}
+
+// compareKeys orders keys by class and then code.
+func compareKeys(a, b Key) int {
+	return cmp.Or(cmp.Compare(a.Class, b.Class), cmp.Compare(a.Code, b.Code))
+}
@@ -0,0 +1,270 @@
+package burst_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors/burst"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/errgroup"
+)
+
+var errTest = errors.New("this is a test error")
+
+var (
+	testCode = errcode.Register(errcode.Definition{Code: "TEST_BURST", Class: errclass.Persistent})
+	custom   = errclass.Register("test-burst-throttled", errclass.Transient)
+)
+
+// clock is a manually advanced clock for [burst.WithClock].
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// alerts collects the alerts of a threshold.
+type alerts struct {
+	mu     sync.Mutex
+	alerts []burst.Alert
+}
+
+func (a *alerts) Notify(alert burst.Alert) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.alerts = append(a.alerts, alert)
+}
+
+func (a *alerts) Take() []burst.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	taken := a.alerts
+	a.alerts = nil
+	return taken
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	d := burst.New(burst.WithClock(c.Now), burst.WithWindow(10*time.Second))
+
+	d.Observe(nil)
+	d.Observe(errTest)
+	d.Observe(errclass.WrapAs(errTest, errclass.Transient))
+	d.Observe(errclass.WrapAs(errTest, errclass.Transient))
+	d.Observe(errcode.Wrap(errTest, testCode))
+
+	got := d.Snapshot()
+	want := burst.Snapshot{
+		Time:   c.Now(),
+		Window: 10 * time.Second,
+		Total:  4,
+		Rates: []burst.Rate{
+			{Key: burst.Key{Class: errclass.Unknown}, Count: 1, PerSecond: 0.1},
+			{Key: burst.Key{Class: errclass.Transient}, Count: 2, PerSecond: 0.2},
+			{Key: burst.Key{Class: errclass.Persistent, Code: testCode}, Count: 1, PerSecond: 0.1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Snapshot() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRollingWindow(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	d := burst.New(burst.WithClock(c.Now), burst.WithWindow(10*time.Second))
+
+	d.Observe(errTest)
+	c.Advance(5 * time.Second)
+	d.Observe(errTest)
+	if got := d.Snapshot().Total; got != 2 {
+		t.Fatalf("Total = %d, want 2", got)
+	}
+
+	// The first error leaves the window.
+	c.Advance(5 * time.Second)
+	if got := d.Snapshot().Total; got != 1 {
+		t.Fatalf("Total = %d, want 1", got)
+	}
+
+	// A bucket is reused once its slice has left the window.
+	c.Advance(time.Hour)
+	d.Observe(errTest)
+	if got := d.Snapshot().Total; got != 1 {
+		t.Fatalf("Total = %d, want 1", got)
+	}
+}
+
+func TestClockBeforeEpoch(t *testing.T) {
+	t.Parallel()
+
+	for _, start := range []time.Time{{}, time.Date(1969, 12, 31, 23, 59, 0, 0, time.UTC)} {
+		t.Run(start.String(), func(t *testing.T) {
+			t.Parallel()
+
+			c := &clock{now: start}
+			d := burst.New(burst.WithClock(c.Now), burst.WithWindow(10*time.Second))
+			for range 12 {
+				d.Observe(errTest)
+				c.Advance(time.Second)
+			}
+			if got := d.Snapshot().Total; got != 9 {
+				t.Fatalf("Total = %d, want 9", got)
+			}
+
+			// Going back in time does not mix slices up.
+			c.Advance(-time.Hour)
+			d.Observe(errTest)
+			if got := d.Snapshot().Total; got != 1 {
+				t.Fatalf("Total = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	var transient, all alerts
+	d := burst.New(
+		burst.WithClock(c.Now),
+		burst.WithWindow(10*time.Second),
+		burst.WithThreshold(burst.Threshold{
+			Name:   "transient",
+			Match:  burst.MatchClass(errclass.Transient),
+			Limit:  2,
+			Notify: transient.Notify,
+		}),
+		burst.WithThreshold(burst.Threshold{Name: "all", Limit: 3, Notify: all.Notify}),
+	)
+
+	errTransient := errclass.WrapAs(errTest, errclass.Transient)
+	d.Observe(errTransient)
+	d.Observe(errTest)
+	if got := transient.Take(); got != nil {
+		t.Fatalf("alerts = %+v, want none", got)
+	}
+
+	d.Observe(errTransient)
+	want := []burst.Alert{{Threshold: "transient", Firing: true, Count: 2, Limit: 2, Window: 10 * time.Second, Err: errTransient}}
+	if got := transient.Take(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("alerts = %+v, want %+v", got, want)
+	}
+	want = []burst.Alert{{Threshold: "all", Firing: true, Count: 3, Limit: 3, Window: 10 * time.Second, Err: errTransient}}
+	if got := all.Take(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("alerts = %+v, want %+v", got, want)
+	}
+
+	// A firing threshold does not fire again.
+	d.Observe(errTransient)
+	if got := transient.Take(); got != nil {
+		t.Fatalf("alerts = %+v, want none", got)
+	}
+	if got := d.Snapshot().Firing; !reflect.DeepEqual(got, []string{"transient", "all"}) {
+		t.Fatalf("Firing = %q, want [transient all]", got)
+	}
+
+	// Thresholds resolve once the errors have left the window.
+	c.Advance(time.Minute)
+	snapshot := d.Snapshot()
+	if snapshot.Firing != nil {
+		t.Fatalf("Firing = %q, want none", snapshot.Firing)
+	}
+	want = []burst.Alert{{Threshold: "transient", Firing: false, Count: 0, Limit: 2, Window: 10 * time.Second}}
+	if got := transient.Take(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("alerts = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		match func(burst.Key) bool
+		key   burst.Key
+		want  bool
+	}{
+		{"class", burst.MatchClass(errclass.Transient), burst.Key{Class: errclass.Transient}, true},
+		{"other class", burst.MatchClass(errclass.Transient), burst.Key{Class: errclass.Persistent}, false},
+		{"registered class severity", burst.MatchClass(errclass.Transient), burst.Key{Class: custom}, true},
+		{"registered class", burst.MatchClass(custom), burst.Key{Class: custom}, true},
+		{"code", burst.MatchCode(testCode), burst.Key{Code: testCode}, true},
+		{"other code", burst.MatchCode(testCode), burst.Key{Code: "OTHER"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.match(tt.key); got != tt.want {
+				t.Errorf("match(%+v) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunc(t *testing.T) {
+	t.Parallel()
+
+	d := burst.New()
+	g := errgroup.New()
+	for i := range 5 {
+		g.Go(d.Func(func() error {
+			if i%2 == 0 {
+				return errTest
+			}
+			return nil
+		}))
+	}
+	if err := g.Wait(); !errors.Is(err, errTest) {
+		t.Fatalf("Wait() = %v, want %v", err, errTest)
+	}
+	if got := d.Snapshot().Total; got != 3 {
+		t.Fatalf("Total = %d, want 3", got)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var fired alerts
+	d := burst.New(burst.WithThreshold(burst.Threshold{Name: "all", Limit: 500, Notify: fired.Notify}))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 100 {
+				d.Observe(errTest)
+			}
+		})
+	}
+	wg.Wait()
+	if got := d.Snapshot().Total; got != 1000 {
+		t.Fatalf("Total = %d, want 1000", got)
+	}
+	if got := fired.Take(); len(got) != 1 || got[0].Count != 500 {
+		t.Fatalf("alerts = %+v, want one at 500", got)
+	}
+}
@@ -0,0 +1,46 @@
+package burst_test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wood-jp/xerrors/burst"
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+func ExampleNew() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	d := burst.New(
+		burst.WithWindow(time.Minute),
+		burst.WithThreshold(burst.Threshold{
+			Name:  "transient",
+			Match: burst.MatchClass(errclass.Transient),
+			Limit: 3,
+			Notify: func(a burst.Alert) {
+				logger.Warn("error burst", slog.Any("alert", a))
+			},
+		}),
+	)
+
+	for range 3 {
+		d.Observe(errclass.WrapAs(errors.New("connection refused"), errclass.Transient))
+	}
+
+	for _, rate := range d.Snapshot().Rates {
+		fmt.Println(rate.Class, rate.Count)
+	}
+	// Output:
+	// level=WARN msg="error burst" alert.threshold=transient alert.firing=true alert.count=3 alert.limit=3 alert.window=1m0s alert.error.error="connection refused" alert.error.error_detail.class=transient
+	// transient 3
+}
@@ -0,0 +1,44 @@
+package burst
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/wood-jp/xerrors/errclass/httpmap"
+)
+
+// Middleware returns an [http.Handler] that calls next and observes every
+// response with a 5xx status as an error, classified from the status by
+// [httpmap.FromHTTPStatus], so that a 503 counts as [errclass.Transient]. Handlers
+// only report a status, so the error carries no code or context; to count the
+// underlying errors instead, call [Detector.Observe] where they are handled.
+// Client errors (4xx) are not observed.
+func (d *Detector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			d.Observe(httpmap.FromHTTPStatus(errors.New(http.StatusText(rec.status)), rec.status))
+		}
+	})
+}
+
+// statusRecorder records the status written to a [http.ResponseWriter].
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader implements [http.ResponseWriter].
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader && status >= http.StatusOK {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying [http.ResponseWriter], for [http.ResponseController].
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,43 @@
+package burst_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/wood-jp/xerrors/burst"
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	d := burst.New()
+	handler := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/internal":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+
+	for _, path := range []string{"/unavailable", "/unavailable", "/internal", "/missing", "/"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	got := d.Snapshot().Rates
+	want := []burst.Rate{
+		{Key: burst.Key{Class: errclass.Transient}, Count: 2, PerSecond: 2.0 / 60},
+		{Key: burst.Key{Class: errclass.Persistent}, Count: 1, PerSecond: 1.0 / 60},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Rates = %+v, want %+v", got, want)
+	}
+}
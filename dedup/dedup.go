@@ -0,0 +1,354 @@
+// Package dedup provides an [slog.Handler] that deduplicates error logs. When a
+// failing dependency produces the same error over and over, the first occurrence
+// is logged in full, and further occurrences are counted and logged as one
+// summary per window for as long as the error keeps occurring, instead of
+// flooding the log.
+//
+// The handler runs a goroutine that writes the summaries when their window ends.
+// Call [Handler.Close] to stop it.
+package dedup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+const (
+	// RepeatedKey is the key of the attr added to summary records, holding the
+	// number of occurrences the summary stands for.
+	RepeatedKey = "repeated"
+	// WindowKey is the key of the attr added to summary records, holding the time
+	// over which the occurrences were counted.
+	WindowKey = "repeated_window"
+
+	// defaultWindow is the default deduplication window.
+	defaultWindow = time.Minute
+	// sweepsPerWindow is the number of times per window that the handler looks for
+	// windows that have ended, so that summaries are written at most a quarter of
+	// a window late.
+	sweepsPerWindow = 4
+)
+
+// entry tracks the occurrences of one error within the current window.
+type entry struct {
+	start     time.Time
+	count     int
+	latest    slog.Record
+	latestCtx context.Context
+	next      slog.Handler
+}
+
+// state is shared by a [Handler] and every handler derived from it with
+// WithAttrs or WithGroup, so that they deduplicate together.
+type state struct {
+	window      time.Duration
+	now         func() time.Time
+	fingerprint []xerrors.FingerprintOption
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// pending is a summary record to be passed to the next handler once the state's
+// lock has been released.
+type pending struct {
+	ctx  context.Context
+	next slog.Handler
+	r    slog.Record
+}
+
+// Handler is an [slog.Handler] that deduplicates records carrying an error.
+// Create one with [NewHandler].
+type Handler struct {
+	next  slog.Handler
+	state *state
+	// err is the error found in the attrs given to WithAttrs, if any.
+	err *recordError
+}
+
+// recordError identifies the error carried by a record.
+type recordError struct {
+	fingerprint string
+	panicked    bool
+}
+
+// Option configures a [Handler] created by [NewHandler].
+type Option func(s *state)
+
+// WithWindow sets the deduplication window. The default is one minute, which is
+// also used if d is not positive.
+func WithWindow(d time.Duration) Option {
+	return func(s *state) {
+		s.window = d
+	}
+}
+
+// WithFingerprint sets the options used with [xerrors.Fingerprint] to decide
+// whether two errors are the same.
+func WithFingerprint(opts ...xerrors.FingerprintOption) Option {
+	return func(s *state) {
+		s.fingerprint = opts
+	}
+}
+
+// WithClock sets the function used to tell the time. The default is [time.Now].
+// The periodic sweep still runs on the system clock, several times per window,
+// and uses now to decide which windows have ended.
+func WithClock(now func() time.Time) Option {
+	return func(s *state) {
+		s.now = now
+	}
+}
+
+// NewHandler returns a [Handler] that passes records to next, deduplicating those
+// that carry an error:
+//
+//   - The first occurrence of an error is passed on in full, and starts a window.
+//   - Further occurrences within the window are dropped and counted.
+//   - When the window ends, if any occurrences were dropped, a summary is passed
+//     on: the latest occurrence, with its own attrs and context, plus
+//     [RepeatedKey] and [WindowKey] attrs, such as repeated=1532
+//     repeated_window=1m0s. A new window then starts, so an error that keeps
+//     occurring is summarised once per window.
+//   - An error that did not occur again during its window is forgotten, and is
+//     logged in full the next time it occurs.
+//
+// Occurrences are the same if they have the same message and their errors have
+// the same [xerrors.Fingerprint]. The error is taken from the first record attr
+// whose value is an error, such as slog.Any("error", err), or which was created by
+// [xerrors.Log]. For the latter the "fingerprint" key is used if it was enabled
+// with [xerrors.SetLogFingerprint], and otherwise only the error message is compared.
+// If the record has no such attr, the last one given to WithAttrs is used, for
+// example through [slog.Logger.With]. Records without an error, and errors
+// classified [errclass.Panic], are always passed on.
+//
+// NewHandler starts a goroutine that writes the summaries of windows as they end.
+// Call [Handler.Close] to stop it, and to log the remaining counts, before exiting.
+// Errors returned by next for summaries written by the goroutine are discarded,
+// as [slog.Logger] does for every record.
+func NewHandler(next slog.Handler, opts ...Option) *Handler {
+	s := &state{
+		window:  defaultWindow,
+		now:     time.Now,
+		entries: map[string]*entry{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.window <= 0 {
+		s.window = defaultWindow
+	}
+	go s.run()
+	return &Handler{next: next, state: s}
+}
+
+// Enabled implements [slog.Handler].
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements [slog.Handler].
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	recErr, ok := h.state.errorOf(r)
+	if !ok && h.err != nil {
+		recErr, ok = *h.err, true
+	}
+	if !ok || recErr.panicked {
+		return h.next.Handle(ctx, r)
+	}
+	key := r.Message + "\x00" + recErr.fingerprint
+
+	s := h.state
+	now := s.now()
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if ok && now.Sub(e.start) < s.window {
+		e.count++
+		e.latest, e.latestCtx, e.next = r.Clone(), ctx, h.next
+		s.mu.Unlock()
+		return nil
+	}
+	repeated := 0
+	var elapsed time.Duration
+	if ok {
+		repeated, elapsed = e.count, now.Sub(e.start)
+	}
+	s.entries[key] = &entry{start: now}
+	s.mu.Unlock()
+
+	if repeated > 0 {
+		// The window ended before the sweep got to it. This occurrence closes it,
+		// so it is counted in the summary.
+		r = summary(r, repeated+1, elapsed)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements [slog.Handler]. If attrs carry an error, records without
+// an error of their own are deduplicated by it.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := &Handler{next: h.next.WithAttrs(attrs), state: h.state, err: h.err}
+	for _, attr := range slices.Backward(attrs) {
+		if recErr, ok := h.state.errorOfAttr(attr); ok {
+			derived.err = &recErr
+			break
+		}
+	}
+	return derived
+}
+
+// WithGroup implements [slog.Handler].
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), state: h.state, err: h.err}
+}
+
+// Flush logs a summary of every error with occurrences that have been dropped
+// but not yet summarised, using the latest occurrence of each, and starts a new
+// window for each. It returns the errors of the underlying handlers, if any.
+func (h *Handler) Flush() error {
+	s := h.state
+	now := s.now()
+	s.mu.Lock()
+	flushed := s.sweep(now)
+	for _, e := range s.entries {
+		if e.count > 0 {
+			flushed = append(flushed, e.summary(now))
+			*e = entry{start: now}
+		}
+	}
+	s.mu.Unlock()
+	return emit(flushed)
+}
+
+// Close stops the goroutine started by [NewHandler] and then calls [Handler.Flush].
+// The handler, and the handlers derived from it, still deduplicate records after
+// Close, but summaries are then only written by Flush. Close may be called more
+// than once, from any of them.
+func (h *Handler) Close() error {
+	s := h.state
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+	return h.Flush()
+}
+
+// run sweeps the entries several times per window until s.stop is closed.
+func (s *state) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.window / sweepsPerWindow)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			due := s.sweep(s.now())
+			s.mu.Unlock()
+			_ = emit(due)
+		}
+	}
+}
+
+// sweep looks for entries whose window has ended. Those with dropped occurrences
+// are summarised and start a new window, and the others are discarded. s.mu must
+// be held.
+func (s *state) sweep(now time.Time) []pending {
+	var due []pending
+	for key, e := range s.entries {
+		if now.Sub(e.start) < s.window {
+			continue
+		}
+		if e.count == 0 {
+			delete(s.entries, key)
+			continue
+		}
+		due = append(due, e.summary(now))
+		*e = entry{start: now}
+	}
+	return due
+}
+
+// summary returns the summary of the dropped occurrences of e.
+func (e *entry) summary(now time.Time) pending {
+	return pending{ctx: e.latestCtx, next: e.next, r: summary(e.latest, e.count, now.Sub(e.start))}
+}
+
+// emit passes each summary to its handler, returning their errors, if any.
+func emit(summaries []pending) error {
+	var errs []error
+	for _, p := range summaries {
+		errs = append(errs, p.next.Handle(p.ctx, p.r))
+	}
+	return errors.Join(errs...)
+}
+
+// summary returns a copy of r with the [RepeatedKey] and [WindowKey] attrs added.
+func summary(r slog.Record, repeated int, elapsed time.Duration) slog.Record {
+	r = r.Clone()
+	r.AddAttrs(slog.Int(RepeatedKey, repeated), slog.Duration(WindowKey, elapsed))
+	return r
+}
+
+// errorOf returns the error carried by the attrs of r. It returns false if r
+// carries no error.
+func (s *state) errorOf(r slog.Record) (recErr recordError, ok bool) {
+	r.Attrs(func(attr slog.Attr) bool {
+		recErr, ok = s.errorOfAttr(attr)
+		return !ok
+	})
+	return recErr, ok
+}
+
+// errorOfAttr is like errorOf for a single attr.
+func (s *state) errorOfAttr(attr slog.Attr) (recordError, bool) {
+	if attr.Value.Kind() == slog.KindGroup {
+		return s.loggedError(attr.Value.Group())
+	}
+	if err, ok := attr.Value.Any().(error); ok {
+		return recordError{
+			fingerprint: xerrors.Fingerprint(err, s.fingerprint...),
+			panicked:    errclass.GetClass(err).Severity() == errclass.Panic,
+		}, true
+	}
+	return recordError{}, false
+}
+
+// loggedError is like errorOf for the attrs of a group created by [xerrors.Log].
+func (s *state) loggedError(group []slog.Attr) (recordError, bool) {
+	var msg, fingerprint string
+	var panicked, ok bool
+	for _, attr := range group {
+		switch attr.Key {
+		case "error":
+			msg, ok = attr.Value.String(), attr.Value.Kind() == slog.KindString
+		case "fingerprint":
+			fingerprint = attr.Value.String()
+		case "error_detail":
+			for _, detail := range attr.Value.Group() {
+				if detail.Key == "class" {
+					class, err := errclass.ParseClass(detail.Value.String())
+					panicked = err == nil && class.Severity() == errclass.Panic
+				}
+			}
+		}
+	}
+	if ok && fingerprint == "" {
+		fingerprint = xerrors.Fingerprint(errors.New(msg), s.fingerprint...)
+	}
+	return recordError{fingerprint: fingerprint, panicked: panicked}, ok
+}
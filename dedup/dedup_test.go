@@ -0,0 +1,398 @@
+package dedup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/dedup"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+)
+
+var errTest = errors.New("this is a test error")
+
+// clock is a manually advanced clock for [dedup.WithClock].
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// buffer is a [bytes.Buffer] that is safe for concurrent use, as summaries are
+// written by the handler's goroutine.
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func newTestLogger(t *testing.T, opts ...dedup.Option) (*slog.Logger, *dedup.Handler, *clock, *buffer) {
+	t.Helper()
+	var buf buffer
+	c := &clock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := dedup.NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), append([]dedup.Option{dedup.WithClock(c.Now)}, opts...)...)
+	t.Cleanup(func() { _ = h.Close() })
+	return slog.New(h), h, c, &buf
+}
+
+func lines(buf *buffer) []string {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	s := strings.TrimSpace(buf.buf.String())
+	buf.buf.Reset()
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	logger, _, c, buf := newTestLogger(t)
+
+	logger.Error("failed", slog.Any("error", errTest), slog.Int("attempt", 1))
+	for i := 2; i <= 5; i++ {
+		c.Advance(time.Second)
+		logger.Error("failed", slog.Any("error", errTest), slog.Int("attempt", i))
+	}
+	got := lines(buf)
+	want := []string{`level=ERROR msg=failed error="this is a test error" attempt=1`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	c.Advance(time.Minute)
+	logger.Error("failed", slog.Any("error", errTest), slog.Int("attempt", 6))
+	got = lines(buf)
+	want = []string{`level=ERROR msg=failed error="this is a test error" attempt=6 repeated=5 repeated_window=1m4s`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// A new window has started.
+	logger.Error("failed", slog.Any("error", errTest), slog.Int("attempt", 7))
+	if got := lines(buf); got != nil {
+		t.Fatalf("got %q, want nothing", got)
+	}
+}
+
+func TestHandlerDistinct(t *testing.T) {
+	t.Parallel()
+
+	logger, _, _, buf := newTestLogger(t)
+
+	logger.Error("failed", slog.Any("error", errTest))
+	logger.Error("failed", slog.Any("error", errors.New("another error")))
+	logger.Error("other message", slog.Any("error", errTest))
+	logger.Error("failed", slog.Any("error", errTest))
+	logger.Info("no error")
+	logger.Info("no error")
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error="this is a test error"`,
+		`level=ERROR msg=failed error="another error"`,
+		`level=ERROR msg="other message" error="this is a test error"`,
+		`level=INFO msg="no error"`,
+		`level=INFO msg="no error"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerFingerprint(t *testing.T) {
+	t.Parallel()
+
+	logger, _, _, buf := newTestLogger(t)
+
+	// Messages that only differ in numbers have the same fingerprint.
+	logger.Error("failed", slog.Any("error", errors.New("user 1 not found")))
+	logger.Error("failed", slog.Any("error", errors.New("user 2 not found")))
+	if got := lines(buf); len(got) != 1 {
+		t.Fatalf("got %q, want one line", got)
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	logger, _, _, buf := newTestLogger(t)
+
+	err := errclass.WrapAs(errTest, errclass.Panic)
+	logger.Error("failed", slog.Any("error", err))
+	logger.Error("failed", slog.Any("error", err))
+	logger.Error("failed", xerrors.Log(err))
+	logger.Error("failed", xerrors.Log(err))
+	if got := lines(buf); len(got) != 4 {
+		t.Fatalf("got %q, want four lines", got)
+	}
+}
+
+func TestHandlerLog(t *testing.T) {
+	t.Parallel()
+
+	logger, h, c, buf := newTestLogger(t)
+
+	logger.Error("failed", xerrors.Log(errcontext.Add(errTest, slog.Int("id", 1))))
+	logger.Error("failed", xerrors.Log(errcontext.Add(errTest, slog.Int("id", 2))))
+	c.Advance(30 * time.Second)
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error.error="this is a test error" error.error_detail.context.id=1`,
+		`level=ERROR msg=failed error.error="this is a test error" error.error_detail.context.id=2 repeated=1 repeated_window=30s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerWithAttrs(t *testing.T) {
+	t.Parallel()
+
+	logger, h, _, buf := newTestLogger(t)
+
+	// Derived loggers share the window; summaries keep the latest logger's attrs.
+	logger.With("request", 1).Error("failed", slog.Any("error", errTest))
+	logger.With("request", 2).WithGroup("g").Error("failed", slog.Any("error", errTest))
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed request=1 error="this is a test error"`,
+		`level=ERROR msg=failed request=2 g.error="this is a test error" g.repeated=1 g.repeated_window=0s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerWithAttrsError(t *testing.T) {
+	t.Parallel()
+
+	logger, h, _, buf := newTestLogger(t)
+
+	// Records are deduplicated by an error given to With, unless they carry their own.
+	withErr := logger.With(slog.Any("error", errTest))
+	withErr.Error("failed", slog.Int("attempt", 1))
+	withErr.Error("failed", slog.Int("attempt", 2))
+	withErr.Error("failed", slog.Any("error", errors.New("another error")))
+	logger.With(xerrors.Log(errTest)).WithGroup("g").Error("failed", slog.Int("attempt", 3))
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error="this is a test error" attempt=1`,
+		`level=ERROR msg=failed error="this is a test error" error="another error"`,
+		`level=ERROR msg=failed error.error="this is a test error" g.attempt=3 g.repeated=2 g.repeated_window=0s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerFlush(t *testing.T) {
+	t.Parallel()
+
+	logger, h, c, buf := newTestLogger(t, dedup.WithWindow(10*time.Second))
+
+	logger.Error("failed", slog.Any("error", errTest))
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := lines(buf); len(got) != 1 {
+		t.Fatalf("got %q, want one line", got)
+	}
+
+	// Flush starts a new window.
+	logger.Error("failed", slog.Any("error", errTest))
+	c.Advance(5 * time.Second)
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("failed", slog.Any("error", errTest))
+	c.Advance(10 * time.Second)
+	logger.Error("failed", slog.Any("error", errTest))
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error="this is a test error" repeated=1 repeated_window=5s`,
+		`level=ERROR msg=failed error="this is a test error" repeated=2 repeated_window=10s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerConcurrent(t *testing.T) {
+	t.Parallel()
+
+	logger, h, _, buf := newTestLogger(t)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 100 {
+				logger.Error("failed", slog.Any("error", errTest))
+			}
+		})
+	}
+	wg.Wait()
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error="this is a test error"`,
+		`level=ERROR msg=failed error="this is a test error" repeated=999 repeated_window=0s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerEnabled(t *testing.T) {
+	t.Parallel()
+
+	h := dedup.NewHandler(slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	t.Cleanup(func() { _ = h.Close() })
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Enabled(Info) = true, want false")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Enabled(Error) = false, want true")
+	}
+}
+
+// waitForLines waits for the handler's goroutine to write n lines to buf.
+func waitForLines(t *testing.T, buf *buffer, n int) []string {
+	t.Helper()
+	var got []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		got = append(got, lines(buf)...)
+		if len(got) >= n {
+			return got
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("got %q, want %d lines", got, n)
+	return nil
+}
+
+func TestHandlerSweep(t *testing.T) {
+	t.Parallel()
+
+	// The handler sweeps every 5ms of real time, and the fake clock decides which
+	// windows have ended.
+	logger, _, c, buf := newTestLogger(t, dedup.WithWindow(20*time.Millisecond))
+
+	errOther := errors.New("another error")
+	logger.Error("failed", slog.Any("error", errTest))
+	logger.Error("failed", slog.Any("error", errTest))
+	logger.Error("failed", slog.Any("error", errOther))
+	lines(buf)
+
+	// Summaries are written when the window ends, even if the error does not occur
+	// again.
+	c.Advance(20 * time.Millisecond)
+	got := waitForLines(t, buf, 1)
+	want := []string{`level=ERROR msg=failed error="this is a test error" repeated=1 repeated_window=20ms`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// The error that was not repeated was forgotten, so it is logged in full again.
+	// The other one is in a new window.
+	logger.Error("failed", slog.Any("error", errOther))
+	logger.Error("failed", slog.Any("error", errTest))
+	got = lines(buf)
+	want = []string{`level=ERROR msg=failed error="another error"`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	c.Advance(20 * time.Millisecond)
+	got = waitForLines(t, buf, 1)
+	want = []string{`level=ERROR msg=failed error="this is a test error" repeated=1 repeated_window=20ms`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerClose(t *testing.T) {
+	t.Parallel()
+
+	logger, h, c, buf := newTestLogger(t, dedup.WithWindow(20*time.Millisecond))
+
+	logger.Error("failed", slog.Any("error", errTest))
+	logger.Error("failed", slog.Any("error", errTest))
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := lines(buf)
+	want := []string{
+		`level=ERROR msg=failed error="this is a test error"`,
+		`level=ERROR msg=failed error="this is a test error" repeated=1 repeated_window=0s`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// Records are still deduplicated, but summaries are no longer written when
+	// windows end.
+	logger.Error("failed", slog.Any("error", errTest))
+	c.Advance(20 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if got := lines(buf); got != nil {
+		t.Fatalf("got %q, want nothing", got)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got = lines(buf)
+	want = []string{`level=ERROR msg=failed error="this is a test error" repeated=1 repeated_window=20ms`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package dedup_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/wood-jp/xerrors/dedup"
+)
+
+func newJSONHandler() slog.Handler {
+	return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			if a.Key == dedup.WindowKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func ExampleNewHandler() {
+	h := dedup.NewHandler(newJSONHandler())
+	defer func() { _ = h.Close() }()
+	logger := slog.New(h)
+
+	err := errors.New("connection refused")
+	for range 1000 {
+		logger.Error("query failed", slog.Any("error", err))
+	}
+	// Summaries are written as windows end. Flush writes the pending ones now.
+	_ = h.Flush()
+	// Output:
+	// {"level":"ERROR","msg":"query failed","error":"connection refused"}
+	// {"level":"ERROR","msg":"query failed","error":"connection refused","repeated":999}
+}
@@ -0,0 +1,83 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+)
+
+// Classifier infers the [Class] of an error that has not been explicitly classified.
+// It returns false if it does not recognize err.
+type Classifier func(err error) (Class, bool)
+
+// classifiers holds the registered classifiers. The list is never modified in
+// place, so that classify can range over it without holding the lock.
+var classifiers struct {
+	mu   sync.RWMutex
+	list []*Classifier
+}
+
+// RegisterClassifier adds c to the chain of classifiers consulted by [GetClass]
+// when an error carries no explicit class. Classifiers are consulted in
+// registration order, and the first to recognize the error wins. All registered
+// classifiers are consulted before the built-in ones, so they can override them.
+//
+// RegisterClassifier is intended to be called during package initialization.
+// It returns a function that removes c from the chain, for use in tests:
+//
+//	t.Cleanup(errclass.RegisterClassifier(classify))
+func RegisterClassifier(c Classifier) (unregister func()) {
+	entry := &c
+	classifiers.mu.Lock()
+	defer classifiers.mu.Unlock()
+	classifiers.list = append(slices.Clip(classifiers.list), entry)
+	return func() {
+		classifiers.mu.Lock()
+		defer classifiers.mu.Unlock()
+		classifiers.list = slices.DeleteFunc(slices.Clone(classifiers.list), func(e *Classifier) bool {
+			return e == entry
+		})
+	}
+}
+
+// classify runs err through the registered classifiers and then the built-in ones.
+func classify(err error) (Class, bool) {
+	classifiers.mu.RLock()
+	list := classifiers.list
+	classifiers.mu.RUnlock()
+	for _, c := range list {
+		if class, ok := (*c)(err); ok {
+			return class, true
+		}
+	}
+	return classifyStd(err)
+}
+
+// classifyStd classifies well-known errors from the standard library.
+func classifyStd(err error) (Class, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.ETIMEDOUT):
+		return Transient, true
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, os.ErrNotExist),
+		errors.Is(err, os.ErrExist),
+		errors.Is(err, os.ErrPermission):
+		return Persistent, true
+	}
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		return Transient, true
+	}
+	return Unknown, false
+}
@@ -0,0 +1,111 @@
+package errclass_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+// timeoutError implements net.Error.
+type timeoutError struct {
+	timeout bool
+}
+
+func (e timeoutError) Error() string   { return "network error" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+// quotaError is only recognized by the classifier registered in this file.
+type quotaError struct{}
+
+func (quotaError) Error() string { return "quota exceeded" }
+
+// errOverridden is a standard library error reclassified by a registered classifier.
+var errOverridden = fmt.Errorf("overridden: %w", io.ErrUnexpectedEOF)
+
+func init() {
+	errclass.RegisterClassifier(func(err error) (errclass.Class, bool) {
+		if _, ok := errors.AsType[quotaError](err); ok {
+			return rateLimited, true
+		}
+		if err == errOverridden { //nolint:errorlint // intentional identity check: only the exact error is reclassified
+			return errclass.Persistent, true
+		}
+		return errclass.Unknown, false
+	})
+}
+
+func TestGetClassClassifiers(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want errclass.Class
+	}{
+		{"context deadline", context.DeadlineExceeded, errclass.Transient},
+		{"wrapped context deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), errclass.Transient},
+		{"context canceled", context.Canceled, errclass.Persistent},
+		{"os deadline", os.ErrDeadlineExceeded, errclass.Transient},
+		{"unexpected EOF", io.ErrUnexpectedEOF, errclass.Transient},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, errclass.Transient},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, errclass.Transient},
+		{"net timeout", timeoutError{timeout: true}, errclass.Transient},
+		{"net non-timeout", timeoutError{timeout: false}, errclass.Unknown},
+		{"not exist", &os.PathError{Op: "open", Path: "/nope", Err: os.ErrNotExist}, errclass.Persistent},
+		{"permission", os.ErrPermission, errclass.Persistent},
+		{"plain EOF is not classified", io.EOF, errclass.Unknown},
+		{"registered classifier", fmt.Errorf("wrapped: %w", quotaError{}), rateLimited},
+		{"registered classifier overrides built-in", errOverridden, errclass.Persistent},
+		{"explicit class wins", errclass.WrapAs(context.DeadlineExceeded, errclass.Persistent), errclass.Persistent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := errclass.GetClass(tt.err); got != tt.want {
+				t.Errorf("GetClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterClassifierUnregister(t *testing.T) {
+	t.Parallel()
+
+	type lockedError struct{ error }
+	err := lockedError{errors.New("database is locked")}
+	unregister := errclass.RegisterClassifier(func(err error) (errclass.Class, bool) {
+		if _, ok := errors.AsType[lockedError](err); ok {
+			return errclass.Transient, true
+		}
+		return errclass.Unknown, false
+	})
+	if got := errclass.GetClass(err); got != errclass.Transient {
+		t.Errorf("GetClass() = %v, want %v", got, errclass.Transient)
+	}
+
+	unregister()
+	if got := errclass.GetClass(err); got != errclass.Unknown {
+		t.Errorf("GetClass() after unregister = %v, want %v", got, errclass.Unknown)
+	}
+	// The classifier registered in init is still consulted.
+	if got := errclass.GetClass(quotaError{}); got != rateLimited {
+		t.Errorf("GetClass(quotaError) = %v, want %v", got, rateLimited)
+	}
+}
+
+func TestWrapAsUsesClassifiers(t *testing.T) {
+	t.Parallel()
+
+	// The inferred class counts as a class, so WithOnlyUnknown leaves it alone.
+	err := errclass.WrapAs(context.DeadlineExceeded, errclass.Persistent, errclass.WithOnlyUnknown())
+	if got := errclass.GetClass(err); got != errclass.Transient {
+		t.Errorf("GetClass() = %v, want %v", got, errclass.Transient)
+	}
+}
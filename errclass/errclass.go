@@ -1,6 +1,7 @@
 // Package errclass provides error classification by severity level.
 // It wraps errors with a [Class] using [xerrors.Extend], enabling downstream
-// callers to inspect and act on error severity.
+// callers to inspect and act on error severity. A [Kind] can also be attached
+// to categorise the failure independently of its severity.
 package errclass
 
 import (
@@ -10,7 +11,9 @@ import (
 )
 
 // Class represents the severity classification of an error.
-// Higher values indicate more severe errors.
+// Higher values indicate more severe errors among the built-in classes.
+// Additional classes can be created with [Register]; use [Class.Severity]
+// to rank them against each other.
 type Class int
 
 const (
@@ -27,11 +30,14 @@ const (
 )
 
 // String returns the lowercase name of the Class.
+// Classes created with [Register] return their registered name.
 // Unrecognized values return "unknown".
 func (c Class) String() string {
 	switch c {
 	case Nil:
 		return "nil"
+	case Unknown:
+		return "unknown"
 	case Panic:
 		return "panic"
 	case Transient:
@@ -39,10 +45,43 @@ func (c Class) String() string {
 	case Persistent:
 		return "persistent"
 	default:
+		if r, ok := lookup(c); ok {
+			return r.name
+		}
 		return "unknown"
 	}
 }
 
+// Severity returns the built-in class that c is ranked alongside.
+// Built-in classes return themselves, classes created with [Register] return
+// the severity they were registered with, and unrecognized values return [Unknown].
+func (c Class) Severity() Class {
+	switch c {
+	case Nil, Unknown, Transient, Persistent, Panic:
+		return c
+	default:
+		if r, ok := lookup(c); ok {
+			return r.severity
+		}
+		return Unknown
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], returning the name of the Class.
+func (c Class) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] using [ParseClass].
+func (c *Class) UnmarshalText(text []byte) error {
+	class, err := ParseClass(string(text))
+	if err != nil {
+		return err
+	}
+	*c = class
+	return nil
+}
+
 // LogValue implements [slog.LogValuer], returning the class name as a grouped slog value.
 func (c Class) LogValue() slog.Value {
 	return slog.GroupValue(
@@ -50,6 +89,11 @@ func (c Class) LogValue() slog.Value {
 	)
 }
 
+// FingerprintComponent implements [xerrors.Fingerprinter], contributing the class name.
+func (c Class) FingerprintComponent() (string, []string) {
+	return "class", []string{c.String()}
+}
+
 type wrappingRestriction int
 
 const (
@@ -75,6 +119,10 @@ func WithUnrestricted() WrapOption {
 
 // WithOnlyUnknown restricts [WrapAs] to only wrap errors whose current class
 // is [Unknown]. Errors that already have a class are returned unchanged.
+//
+// The current class is the one returned by [GetClass], so it includes classes
+// inferred by classifiers: for example, an error wrapping
+// [context.DeadlineExceeded] is already [Transient] and is left unchanged.
 func WithOnlyUnknown() WrapOption {
 	return func(opt *wrapOptions) {
 		opt.restriction = wrappingRestrictionOnlyUnknown
@@ -82,8 +130,9 @@ func WithOnlyUnknown() WrapOption {
 }
 
 // WithOnlyMoreSevere restricts [WrapAs] to only wrap errors when the provided
-// class is strictly more severe than the error's current class. Otherwise the
-// error is returned unchanged.
+// class is strictly more severe than the error's current class, as ranked by
+// [Class.Severity]. Otherwise the error is returned unchanged. As with
+// [WithOnlyUnknown], the current class includes classes inferred by classifiers.
 func WithOnlyMoreSevere() WrapOption {
 	return func(opt *wrapOptions) {
 		opt.restriction = wrappingRestrictionOnlyMoreSevere
@@ -114,7 +163,7 @@ func WrapAs(err error, class Class, opts ...WrapOption) error {
 		return err
 
 	case wrappingRestrictionOnlyMoreSevere:
-		if class > currentClass {
+		if class.Severity() > currentClass.Severity() {
 			return xerrors.Extend(class, err)
 		}
 		return err
@@ -124,8 +173,10 @@ func WrapAs(err error, class Class, opts ...WrapOption) error {
 	}
 }
 
-// GetClass extracts the [Class] from err. It returns [Nil] if err is nil,
-// and [Unknown] if err does not carry a class.
+// GetClass extracts the [Class] from err. It returns [Nil] if err is nil.
+// If err does not carry a class, the classifiers added with [RegisterClassifier]
+// and then the built-in classifiers for standard library errors are consulted.
+// [Unknown] is returned if none of them recognize err.
 func GetClass(err error) Class {
 	if err == nil {
 		return Nil
@@ -133,5 +184,8 @@ func GetClass(err error) Class {
 	if class, ok := xerrors.Extract[Class](err); ok {
 		return class
 	}
+	if class, ok := classify(err); ok {
+		return class
+	}
 	return Unknown
 }
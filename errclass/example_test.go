@@ -1,6 +1,7 @@
 package errclass_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -61,3 +62,46 @@ func ExampleWithOnlyUnknown() {
 	// Output:
 	// transient
 }
+
+func ExampleRegister() {
+	rateLimited := errclass.Register("rate_limited", errclass.Transient)
+	err := errclass.WrapAs(errors.New("too many requests"), rateLimited)
+	class := errclass.GetClass(err)
+	fmt.Println(class, class.Severity())
+	newLogger().Error("operation failed", xerrors.Log(err))
+	// Output:
+	// rate_limited transient
+	// {"level":"ERROR","msg":"operation failed","error":{"error":"too many requests","error_detail":{"class":"rate_limited"}}}
+}
+
+func ExampleParseClass() {
+	class, err := errclass.ParseClass("persistent")
+	fmt.Println(class, err)
+	// Output:
+	// persistent <nil>
+}
+
+func ExampleWrapKind() {
+	err := errclass.WrapKind(errors.New("user not found"), errclass.KindNotFound)
+	fmt.Println(errclass.GetKind(err), errclass.GetClass(err))
+	newLogger().Error("lookup failed", xerrors.Log(err))
+	// Output:
+	// not_found persistent
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"user not found","error_detail":{"kind":"not_found","class":"persistent"}}}
+}
+
+func ExampleRegisterClassifier() {
+	unregister := errclass.RegisterClassifier(func(err error) (errclass.Class, bool) {
+		if err.Error() == "database is locked" {
+			return errclass.Transient, true
+		}
+		return errclass.Unknown, false
+	})
+	defer unregister()
+
+	fmt.Println(errclass.GetClass(errors.New("database is locked")))
+	fmt.Println(errclass.GetClass(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	// Output:
+	// transient
+	// transient
+}
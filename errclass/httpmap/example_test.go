@@ -0,0 +1,32 @@
+package httpmap_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errclass/httpmap"
+)
+
+func ExampleHTTPStatus() {
+	err := errclass.WrapKind(errors.New("user not found"), errclass.KindNotFound)
+	fmt.Println(httpmap.HTTPStatus(err), httpmap.GRPCCode(err))
+	// Output:
+	// 404 5
+}
+
+func ExampleFromHTTPStatus() {
+	err := httpmap.FromHTTPStatus(errors.New("upstream request failed"), http.StatusServiceUnavailable)
+	fmt.Println(errclass.GetKind(err), errclass.GetClass(err))
+	// Output:
+	// unavailable transient
+}
+
+func ExampleNew() {
+	m := httpmap.New(httpmap.WithKindHTTPStatus(errclass.KindNotFound, http.StatusGone))
+	err := errclass.WrapKind(errors.New("user deleted"), errclass.KindNotFound)
+	fmt.Println(m.HTTPStatus(err))
+	// Output:
+	// 410
+}
@@ -0,0 +1,294 @@
+// Package httpmap translates between [errclass] classifications and transport
+// status codes. A [Mapper] maps an error's [errclass.Kind] and [errclass.Class]
+// to an HTTP status code or a gRPC status code, and maps the status codes of
+// inbound responses back into classified errors.
+//
+// gRPC status codes are represented by their numeric value, as defined by
+// google.golang.org/grpc/codes, so that this package does not depend on gRPC.
+// Convert with codes.Code(code) and uint32(code) respectively.
+package httpmap
+
+import (
+	"net/http"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+// Numeric values of the gRPC status codes.
+const (
+	grpcOK                 uint32 = 0
+	grpcCanceled           uint32 = 1
+	grpcUnknown            uint32 = 2
+	grpcInvalidArgument    uint32 = 3
+	grpcDeadlineExceeded   uint32 = 4
+	grpcNotFound           uint32 = 5
+	grpcAlreadyExists      uint32 = 6
+	grpcPermissionDenied   uint32 = 7
+	grpcResourceExhausted  uint32 = 8
+	grpcFailedPrecondition uint32 = 9
+	grpcAborted            uint32 = 10
+	grpcOutOfRange         uint32 = 11
+	grpcUnimplemented      uint32 = 12
+	grpcInternal           uint32 = 13
+	grpcUnavailable        uint32 = 14
+	grpcDataLoss           uint32 = 15
+	grpcUnauthenticated    uint32 = 16
+)
+
+// statusClientClosedRequest is the non-standard status used by nginx (and others)
+// when the client goes away before the response is written.
+const statusClientClosedRequest = 499
+
+// classification is the result of mapping an inbound status code back to an error.
+type classification struct {
+	kind  errclass.Kind
+	class errclass.Class
+}
+
+// Mapper holds the mappings between error classifications and status codes.
+// A Mapper is immutable once created by [New] and safe for concurrent use.
+type Mapper struct {
+	kindStatus  map[errclass.Kind]int
+	classStatus map[errclass.Class]int
+	kindCode    map[errclass.Kind]uint32
+	classCode   map[errclass.Class]uint32
+	fromStatus  map[int]classification
+	fromCode    map[uint32]classification
+}
+
+// Option configures a [Mapper] created by [New].
+type Option func(m *Mapper)
+
+// WithKindHTTPStatus maps errors of the given kind to status, overriding the default.
+func WithKindHTTPStatus(kind errclass.Kind, status int) Option {
+	return func(m *Mapper) {
+		m.kindStatus[kind] = status
+	}
+}
+
+// WithClassHTTPStatus maps errors of the given class to status, overriding the default.
+// Class mappings are only used when the error's kind has no mapping.
+func WithClassHTTPStatus(class errclass.Class, status int) Option {
+	return func(m *Mapper) {
+		m.classStatus[class] = status
+	}
+}
+
+// WithKindGRPCCode maps errors of the given kind to the gRPC code, overriding the default.
+func WithKindGRPCCode(kind errclass.Kind, code uint32) Option {
+	return func(m *Mapper) {
+		m.kindCode[kind] = code
+	}
+}
+
+// WithClassGRPCCode maps errors of the given class to the gRPC code, overriding the default.
+// Class mappings are only used when the error's kind has no mapping.
+func WithClassGRPCCode(class errclass.Class, code uint32) Option {
+	return func(m *Mapper) {
+		m.classCode[class] = code
+	}
+}
+
+// WithHTTPStatusClass makes [Mapper.FromHTTPStatus] categorise errors for status
+// with kind and classify them with class, overriding the default.
+// [errclass.KindUnknown] and [errclass.Unknown] leave the error uncategorised and
+// unclassified respectively.
+func WithHTTPStatusClass(status int, kind errclass.Kind, class errclass.Class) Option {
+	return func(m *Mapper) {
+		m.fromStatus[status] = classification{kind: kind, class: class}
+	}
+}
+
+// WithGRPCCodeClass makes [Mapper.FromGRPCCode] categorise errors for code
+// with kind and classify them with class, overriding the default.
+// [errclass.KindUnknown] and [errclass.Unknown] leave the error uncategorised and
+// unclassified respectively.
+func WithGRPCCodeClass(code uint32, kind errclass.Kind, class errclass.Class) Option {
+	return func(m *Mapper) {
+		m.fromCode[code] = classification{kind: kind, class: class}
+	}
+}
+
+// New returns a [Mapper] with the default mappings, modified by opts.
+func New(opts ...Option) *Mapper {
+	m := &Mapper{
+		kindStatus: map[errclass.Kind]int{
+			errclass.KindCanceled:          statusClientClosedRequest,
+			errclass.KindInvalidArgument:   http.StatusBadRequest,
+			errclass.KindTimeout:           http.StatusGatewayTimeout,
+			errclass.KindNotFound:          http.StatusNotFound,
+			errclass.KindConflict:          http.StatusConflict,
+			errclass.KindPermissionDenied:  http.StatusForbidden,
+			errclass.KindUnauthenticated:   http.StatusUnauthorized,
+			errclass.KindResourceExhausted: http.StatusTooManyRequests,
+			errclass.KindUnimplemented:     http.StatusNotImplemented,
+			errclass.KindUnavailable:       http.StatusServiceUnavailable,
+			errclass.KindInternal:          http.StatusInternalServerError,
+		},
+		classStatus: map[errclass.Class]int{
+			errclass.Nil:        http.StatusOK,
+			errclass.Unknown:    http.StatusInternalServerError,
+			errclass.Transient:  http.StatusServiceUnavailable,
+			errclass.Persistent: http.StatusInternalServerError,
+			errclass.Panic:      http.StatusInternalServerError,
+		},
+		kindCode: map[errclass.Kind]uint32{
+			errclass.KindCanceled:          grpcCanceled,
+			errclass.KindInvalidArgument:   grpcInvalidArgument,
+			errclass.KindTimeout:           grpcDeadlineExceeded,
+			errclass.KindNotFound:          grpcNotFound,
+			errclass.KindConflict:          grpcAlreadyExists,
+			errclass.KindPermissionDenied:  grpcPermissionDenied,
+			errclass.KindUnauthenticated:   grpcUnauthenticated,
+			errclass.KindResourceExhausted: grpcResourceExhausted,
+			errclass.KindUnimplemented:     grpcUnimplemented,
+			errclass.KindUnavailable:       grpcUnavailable,
+			errclass.KindInternal:          grpcInternal,
+		},
+		classCode: map[errclass.Class]uint32{
+			errclass.Nil:        grpcOK,
+			errclass.Unknown:    grpcUnknown,
+			errclass.Transient:  grpcUnavailable,
+			errclass.Persistent: grpcInternal,
+			errclass.Panic:      grpcInternal,
+		},
+		fromStatus: map[int]classification{
+			http.StatusBadRequest:          {errclass.KindInvalidArgument, errclass.Persistent},
+			http.StatusUnauthorized:        {errclass.KindUnauthenticated, errclass.Persistent},
+			http.StatusForbidden:           {errclass.KindPermissionDenied, errclass.Persistent},
+			http.StatusNotFound:            {errclass.KindNotFound, errclass.Persistent},
+			http.StatusRequestTimeout:      {errclass.KindTimeout, errclass.Transient},
+			http.StatusConflict:            {errclass.KindConflict, errclass.Persistent},
+			http.StatusTooManyRequests:     {errclass.KindResourceExhausted, errclass.Transient},
+			statusClientClosedRequest:      {errclass.KindCanceled, errclass.Persistent},
+			http.StatusInternalServerError: {errclass.KindInternal, errclass.Persistent},
+			http.StatusNotImplemented:      {errclass.KindUnimplemented, errclass.Persistent},
+			http.StatusBadGateway:          {errclass.KindUnavailable, errclass.Transient},
+			http.StatusServiceUnavailable:  {errclass.KindUnavailable, errclass.Transient},
+			http.StatusGatewayTimeout:      {errclass.KindTimeout, errclass.Transient},
+		},
+		fromCode: map[uint32]classification{
+			grpcCanceled:           {errclass.KindCanceled, errclass.Persistent},
+			grpcInvalidArgument:    {errclass.KindInvalidArgument, errclass.Persistent},
+			grpcDeadlineExceeded:   {errclass.KindTimeout, errclass.Transient},
+			grpcNotFound:           {errclass.KindNotFound, errclass.Persistent},
+			grpcAlreadyExists:      {errclass.KindConflict, errclass.Persistent},
+			grpcPermissionDenied:   {errclass.KindPermissionDenied, errclass.Persistent},
+			grpcResourceExhausted:  {errclass.KindResourceExhausted, errclass.Transient},
+			grpcFailedPrecondition: {errclass.KindUnknown, errclass.Persistent},
+			grpcAborted:            {errclass.KindConflict, errclass.Transient},
+			grpcOutOfRange:         {errclass.KindInvalidArgument, errclass.Persistent},
+			grpcUnimplemented:      {errclass.KindUnimplemented, errclass.Persistent},
+			grpcInternal:           {errclass.KindInternal, errclass.Persistent},
+			grpcUnavailable:        {errclass.KindUnavailable, errclass.Transient},
+			grpcDataLoss:           {errclass.KindInternal, errclass.Persistent},
+			grpcUnauthenticated:    {errclass.KindUnauthenticated, errclass.Persistent},
+		},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+var defaultMapper = New()
+
+// HTTPStatus returns the HTTP status code for err.
+// The error's [errclass.Kind] is consulted first, then its exact [errclass.Class],
+// then the class's [errclass.Class.Severity]. A nil error maps to 200 OK, and
+// anything unmapped to 500 Internal Server Error.
+func (m *Mapper) HTTPStatus(err error) int {
+	if status, ok := m.kindStatus[errclass.GetKind(err)]; ok {
+		return status
+	}
+	class := errclass.GetClass(err)
+	if status, ok := m.classStatus[class]; ok {
+		return status
+	}
+	if status, ok := m.classStatus[class.Severity()]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the numeric gRPC status code for err, using the same lookup
+// order as [Mapper.HTTPStatus]. A nil error maps to OK (0), and anything
+// unmapped to Unknown (2).
+func (m *Mapper) GRPCCode(err error) uint32 {
+	if code, ok := m.kindCode[errclass.GetKind(err)]; ok {
+		return code
+	}
+	class := errclass.GetClass(err)
+	if code, ok := m.classCode[class]; ok {
+		return code
+	}
+	if code, ok := m.classCode[class.Severity()]; ok {
+		return code
+	}
+	return grpcUnknown
+}
+
+// FromHTTPStatus categorises and classifies err according to the HTTP status
+// code of the response it came from. Any existing class is overridden.
+// If err is nil, it returns nil. Statuses without a mapping return err
+// unchanged, except for other 4xx statuses which are classified [errclass.Persistent].
+func (m *Mapper) FromHTTPStatus(err error, status int) error {
+	c, ok := m.fromStatus[status]
+	if !ok && status >= 400 && status < 500 {
+		c, ok = classification{class: errclass.Persistent}, true
+	}
+	if !ok {
+		return err
+	}
+	return c.apply(err)
+}
+
+// FromGRPCCode categorises and classifies err according to the numeric gRPC
+// status code of the response it came from. Any existing class is overridden.
+// If err is nil, it returns nil. Codes without a mapping return err unchanged.
+func (m *Mapper) FromGRPCCode(err error, code uint32) error {
+	c, ok := m.fromCode[code]
+	if !ok {
+		return err
+	}
+	return c.apply(err)
+}
+
+// apply attaches the kind and class to err, skipping whichever is unknown.
+func (c classification) apply(err error) error {
+	if err == nil {
+		return nil
+	}
+	if c.kind != errclass.KindUnknown {
+		err = xerrors.Extend(c.kind, err)
+	}
+	if c.class != errclass.Unknown {
+		err = errclass.WrapAs(err, c.class)
+	}
+	return err
+}
+
+// HTTPStatus returns the HTTP status code for err using the default mappings.
+// See [Mapper.HTTPStatus].
+func HTTPStatus(err error) int {
+	return defaultMapper.HTTPStatus(err)
+}
+
+// GRPCCode returns the numeric gRPC status code for err using the default mappings.
+// See [Mapper.GRPCCode].
+func GRPCCode(err error) uint32 {
+	return defaultMapper.GRPCCode(err)
+}
+
+// FromHTTPStatus categorises and classifies err using the default mappings.
+// See [Mapper.FromHTTPStatus].
+func FromHTTPStatus(err error, status int) error {
+	return defaultMapper.FromHTTPStatus(err, status)
+}
+
+// FromGRPCCode categorises and classifies err using the default mappings.
+// See [Mapper.FromGRPCCode].
+func FromGRPCCode(err error, code uint32) error {
+	return defaultMapper.FromGRPCCode(err, code)
+}
@@ -0,0 +1,166 @@
+package httpmap_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errclass/httpmap"
+)
+
+var (
+	errTest     = fmt.Errorf("this is a test error")
+	rateLimited = errclass.Register("httpmap_test_rate_limited", errclass.Transient)
+)
+
+func TestHTTPStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   uint32
+	}{
+		{"nil", nil, http.StatusOK, 0},
+		{"unclassified", errTest, http.StatusInternalServerError, 2},
+		{"transient", errclass.WrapAs(errTest, errclass.Transient), http.StatusServiceUnavailable, 14},
+		{"persistent", errclass.WrapAs(errTest, errclass.Persistent), http.StatusInternalServerError, 13},
+		{"panic", errclass.WrapAs(errTest, errclass.Panic), http.StatusInternalServerError, 13},
+		{"registered class uses severity", errclass.WrapAs(errTest, rateLimited), http.StatusServiceUnavailable, 14},
+		{"kind takes precedence", errclass.WrapKind(errTest, errclass.KindNotFound), http.StatusNotFound, 5},
+		{"kind with overridden class", errclass.WrapAs(errclass.WrapKind(errTest, errclass.KindResourceExhausted), errclass.Persistent), http.StatusTooManyRequests, 8},
+		{"wrapped", fmt.Errorf("wrapping: %w", errclass.WrapKind(errTest, errclass.KindPermissionDenied)), http.StatusForbidden, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := httpmap.HTTPStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := httpmap.GRPCCode(tt.err); got != tt.wantCode {
+				t.Errorf("GRPCCode() = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMapperOverrides(t *testing.T) {
+	t.Parallel()
+
+	m := httpmap.New(
+		httpmap.WithClassHTTPStatus(rateLimited, http.StatusTooManyRequests),
+		httpmap.WithClassGRPCCode(rateLimited, 8),
+		httpmap.WithKindHTTPStatus(errclass.KindNotFound, http.StatusGone),
+		httpmap.WithKindGRPCCode(errclass.KindNotFound, 9),
+	)
+
+	err := errclass.WrapAs(errTest, rateLimited)
+	if got := m.HTTPStatus(err); got != http.StatusTooManyRequests {
+		t.Errorf("HTTPStatus() = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := m.GRPCCode(err); got != 8 {
+		t.Errorf("GRPCCode() = %d, want 8", got)
+	}
+
+	err = errclass.WrapKind(errTest, errclass.KindNotFound)
+	if got := m.HTTPStatus(err); got != http.StatusGone {
+		t.Errorf("HTTPStatus() = %d, want %d", got, http.StatusGone)
+	}
+	if got := m.GRPCCode(err); got != 9 {
+		t.Errorf("GRPCCode() = %d, want 9", got)
+	}
+
+	// The default mapper is unaffected.
+	if got := httpmap.HTTPStatus(err); got != http.StatusNotFound {
+		t.Errorf("HTTPStatus() = %d, want %d", got, http.StatusNotFound)
+	}
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		status    int
+		wantKind  errclass.Kind
+		wantClass errclass.Class
+	}{
+		{"nil", nil, http.StatusServiceUnavailable, errclass.KindUnknown, errclass.Nil},
+		{"success leaves error alone", errTest, http.StatusOK, errclass.KindUnknown, errclass.Unknown},
+		{"service unavailable", errTest, http.StatusServiceUnavailable, errclass.KindUnavailable, errclass.Transient},
+		{"too many requests", errTest, http.StatusTooManyRequests, errclass.KindResourceExhausted, errclass.Transient},
+		{"not found", errTest, http.StatusNotFound, errclass.KindNotFound, errclass.Persistent},
+		{"other client error", errTest, http.StatusTeapot, errclass.KindUnknown, errclass.Persistent},
+		{"other server error", errTest, http.StatusHTTPVersionNotSupported, errclass.KindUnknown, errclass.Unknown},
+		{"overrides existing class", errclass.WrapAs(errTest, errclass.Persistent), http.StatusBadGateway, errclass.KindUnavailable, errclass.Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := httpmap.FromHTTPStatus(tt.err, tt.status)
+			if tt.err != nil && !errors.Is(got, errTest) {
+				t.Error("mapped error does not unwrap to original")
+			}
+			if gotKind := errclass.GetKind(got); gotKind != tt.wantKind {
+				t.Errorf("GetKind() = %v, want %v", gotKind, tt.wantKind)
+			}
+			if gotClass := errclass.GetClass(got); gotClass != tt.wantClass {
+				t.Errorf("GetClass() = %v, want %v", gotClass, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestFromGRPCCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		code      uint32
+		wantKind  errclass.Kind
+		wantClass errclass.Class
+	}{
+		{"ok", 0, errclass.KindUnknown, errclass.Unknown},
+		{"unknown", 2, errclass.KindUnknown, errclass.Unknown},
+		{"deadline exceeded", 4, errclass.KindTimeout, errclass.Transient},
+		{"not found", 5, errclass.KindNotFound, errclass.Persistent},
+		{"failed precondition", 9, errclass.KindUnknown, errclass.Persistent},
+		{"aborted", 10, errclass.KindConflict, errclass.Transient},
+		{"unavailable", 14, errclass.KindUnavailable, errclass.Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := httpmap.FromGRPCCode(errTest, tt.code)
+			if gotKind := errclass.GetKind(got); gotKind != tt.wantKind {
+				t.Errorf("GetKind() = %v, want %v", gotKind, tt.wantKind)
+			}
+			if gotClass := errclass.GetClass(got); gotClass != tt.wantClass {
+				t.Errorf("GetClass() = %v, want %v", gotClass, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestMapperReverseOverrides(t *testing.T) {
+	t.Parallel()
+
+	m := httpmap.New(
+		httpmap.WithHTTPStatusClass(http.StatusTooManyRequests, errclass.KindResourceExhausted, rateLimited),
+		httpmap.WithGRPCCodeClass(2, errclass.KindInternal, errclass.Transient),
+	)
+
+	err := m.FromHTTPStatus(errTest, http.StatusTooManyRequests)
+	if got := errclass.GetClass(err); got != rateLimited {
+		t.Errorf("GetClass() = %v, want %v", got, rateLimited)
+	}
+
+	err = m.FromGRPCCode(errTest, 2)
+	if got := errclass.GetKind(err); got != errclass.KindInternal {
+		t.Errorf("GetKind() = %v, want %v", got, errclass.KindInternal)
+	}
+	if got := errclass.GetClass(err); got != errclass.Transient {
+		t.Errorf("GetClass() = %v, want %v", got, errclass.Transient)
+	}
+}
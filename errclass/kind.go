@@ -0,0 +1,115 @@
+package errclass
+
+import (
+	"log/slog"
+
+	"github.com/wood-jp/xerrors"
+)
+
+// Kind categorises what went wrong, independent of whether it may succeed on retry.
+// The canonical kinds mirror gRPC status codes and their HTTP equivalents.
+type Kind int
+
+const (
+	// KindUnknown is the zero value, used for errors that have not been categorised.
+	KindUnknown Kind = iota
+	// KindCanceled indicates the operation was canceled by the caller.
+	KindCanceled
+	// KindInvalidArgument indicates the request failed validation.
+	KindInvalidArgument
+	// KindTimeout indicates the operation did not complete before its deadline.
+	KindTimeout
+	// KindNotFound indicates a requested entity does not exist.
+	KindNotFound
+	// KindConflict indicates the request conflicts with the current state, such as an entity that already exists.
+	KindConflict
+	// KindPermissionDenied indicates the caller is not allowed to perform the operation.
+	KindPermissionDenied
+	// KindUnauthenticated indicates the caller could not be identified.
+	KindUnauthenticated
+	// KindResourceExhausted indicates a quota or rate limit has been reached.
+	KindResourceExhausted
+	// KindUnimplemented indicates the operation is not supported.
+	KindUnimplemented
+	// KindUnavailable indicates a dependency is temporarily unable to serve the request.
+	KindUnavailable
+	// KindInternal indicates an invariant was broken inside the system.
+	KindInternal
+)
+
+// String returns the lowercase name of the Kind.
+// Unrecognized values return "unknown".
+func (k Kind) String() string {
+	switch k {
+	case KindCanceled:
+		return "canceled"
+	case KindInvalidArgument:
+		return "invalid_argument"
+	case KindTimeout:
+		return "timeout"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindPermissionDenied:
+		return "permission_denied"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	case KindResourceExhausted:
+		return "resource_exhausted"
+	case KindUnimplemented:
+		return "unimplemented"
+	case KindUnavailable:
+		return "unavailable"
+	case KindInternal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// Class returns the default severity [Class] for the Kind: [Transient] for
+// [KindTimeout], [KindResourceExhausted] and [KindUnavailable], [Unknown] for
+// [KindUnknown], and [Persistent] for every other kind.
+func (k Kind) Class() Class {
+	switch k {
+	case KindTimeout, KindResourceExhausted, KindUnavailable:
+		return Transient
+	case KindCanceled, KindInvalidArgument, KindNotFound, KindConflict, KindPermissionDenied,
+		KindUnauthenticated, KindUnimplemented, KindInternal:
+		return Persistent
+	default:
+		return Unknown
+	}
+}
+
+// LogValue implements [slog.LogValuer], returning the kind name as a grouped slog value.
+func (k Kind) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("kind", k.String()),
+	)
+}
+
+// WrapKind wraps err with the given [Kind] and classifies it with the kind's
+// default [Class] in a single call. If err is nil, it returns nil.
+// The kind is always attached; opts restrict only the classification, exactly
+// as they do for [WrapAs]. [KindUnknown] leaves the class untouched.
+func WrapKind(err error, kind Kind, opts ...WrapOption) error {
+	if err == nil {
+		return nil
+	}
+	err = xerrors.Extend(kind, err)
+	if class := kind.Class(); class != Unknown {
+		err = WrapAs(err, class, opts...)
+	}
+	return err
+}
+
+// GetKind extracts the [Kind] from err. It returns [KindUnknown] if err is nil
+// or does not carry a kind.
+func GetKind(err error) Kind {
+	if kind, ok := xerrors.Extract[Kind](err); ok {
+		return kind
+	}
+	return KindUnknown
+}
@@ -0,0 +1,119 @@
+package errclass_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+func TestKindString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		kind      errclass.Kind
+		wantName  string
+		wantClass errclass.Class
+	}{
+		{errclass.KindUnknown, "unknown", errclass.Unknown},
+		{errclass.KindCanceled, "canceled", errclass.Persistent},
+		{errclass.KindInvalidArgument, "invalid_argument", errclass.Persistent},
+		{errclass.KindTimeout, "timeout", errclass.Transient},
+		{errclass.KindNotFound, "not_found", errclass.Persistent},
+		{errclass.KindConflict, "conflict", errclass.Persistent},
+		{errclass.KindPermissionDenied, "permission_denied", errclass.Persistent},
+		{errclass.KindUnauthenticated, "unauthenticated", errclass.Persistent},
+		{errclass.KindResourceExhausted, "resource_exhausted", errclass.Transient},
+		{errclass.KindUnimplemented, "unimplemented", errclass.Persistent},
+		{errclass.KindUnavailable, "unavailable", errclass.Transient},
+		{errclass.KindInternal, "internal", errclass.Persistent},
+		{errclass.Kind(99), "unknown", errclass.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.kind.String(); got != tt.wantName {
+				t.Errorf("Kind(%d).String() = %q, want %q", tt.kind, got, tt.wantName)
+			}
+			if got := tt.kind.Class(); got != tt.wantClass {
+				t.Errorf("Kind(%d).Class() = %v, want %v", tt.kind, got, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestKindLogValue(t *testing.T) {
+	t.Parallel()
+	val := errclass.KindNotFound.LogValue()
+	if val.Kind() != slog.KindGroup {
+		t.Fatalf("LogValue().Kind() = %v, want %v", val.Kind(), slog.KindGroup)
+	}
+	attrs := val.Group()
+	if len(attrs) != 1 || attrs[0].Key != "kind" || attrs[0].Value.String() != "not_found" {
+		t.Errorf("LogValue() attrs = %v, want kind=not_found", attrs)
+	}
+}
+
+func TestWrapKind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		kind      errclass.Kind
+		opts      []errclass.WrapOption
+		wantKind  errclass.Kind
+		wantClass errclass.Class
+	}{
+		{
+			name:      "nil error returns nil",
+			err:       nil,
+			kind:      errclass.KindNotFound,
+			wantKind:  errclass.KindUnknown,
+			wantClass: errclass.Nil,
+		},
+		{
+			name:      "categorises and classifies",
+			err:       errors.New("plain"),
+			kind:      errclass.KindUnavailable,
+			wantKind:  errclass.KindUnavailable,
+			wantClass: errclass.Transient,
+		},
+		{
+			name:      "overrides existing class by default",
+			err:       errclass.WrapAs(errors.New("err"), errclass.Transient),
+			kind:      errclass.KindNotFound,
+			wantKind:  errclass.KindNotFound,
+			wantClass: errclass.Persistent,
+		},
+		{
+			name:      "options restrict classification only",
+			err:       errclass.WrapAs(errors.New("err"), errclass.Transient),
+			kind:      errclass.KindNotFound,
+			opts:      []errclass.WrapOption{errclass.WithOnlyUnknown()},
+			wantKind:  errclass.KindNotFound,
+			wantClass: errclass.Transient,
+		},
+		{
+			name:      "unknown kind leaves class untouched",
+			err:       errclass.WrapAs(errors.New("err"), errclass.Persistent),
+			kind:      errclass.KindUnknown,
+			wantKind:  errclass.KindUnknown,
+			wantClass: errclass.Persistent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := errclass.WrapKind(tt.err, tt.kind, tt.opts...)
+			if tt.err != nil && !errors.Is(got, tt.err) {
+				t.Error("wrapped error does not unwrap to original")
+			}
+			if gotKind := errclass.GetKind(got); gotKind != tt.wantKind {
+				t.Errorf("GetKind() = %v, want %v", gotKind, tt.wantKind)
+			}
+			if gotClass := errclass.GetClass(got); gotClass != tt.wantClass {
+				t.Errorf("GetClass() = %v, want %v", gotClass, tt.wantClass)
+			}
+		})
+	}
+}
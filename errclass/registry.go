@@ -0,0 +1,102 @@
+package errclass
+
+import (
+	"fmt"
+	"sync"
+)
+
+// firstRegistered is the value given to the first class created with [Register].
+// It leaves room below for any built-in classes added in the future.
+const firstRegistered Class = 100
+
+type registered struct {
+	name     string
+	severity Class
+}
+
+var registry = struct {
+	mu     sync.RWMutex
+	next   Class
+	byID   map[Class]registered
+	byName map[string]Class
+}{
+	next:   firstRegistered,
+	byID:   map[Class]registered{},
+	byName: map[string]Class{},
+}
+
+// Register creates a new [Class] with the given name, ranked alongside the
+// built-in severity class (one of [Transient], [Persistent] or [Panic]).
+// The returned class works with [Class.String], [Class.LogValue], [WrapAs],
+// [GetClass] and [ParseClass] like the built-in classes do.
+//
+// Register is intended to be called during package initialization:
+//
+//	var RateLimited = errclass.Register("rate_limited", errclass.Transient)
+//
+// Register panics if name is empty or already in use, or if severity is not
+// one of the permitted built-in classes.
+func Register(name string, severity Class) Class {
+	if name == "" {
+		panic("errclass: Register called with empty name")
+	}
+	switch severity {
+	case Transient, Persistent, Panic:
+	default:
+		panic(fmt.Sprintf("errclass: Register called with invalid severity %d for %q", severity, name))
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := builtin(name); ok {
+		panic(fmt.Sprintf("errclass: Register called with built-in class name %q", name))
+	}
+	if _, ok := registry.byName[name]; ok {
+		panic(fmt.Sprintf("errclass: Register called twice for %q", name))
+	}
+	class := registry.next
+	registry.next++
+	registry.byID[class] = registered{name: name, severity: severity}
+	registry.byName[name] = class
+	return class
+}
+
+// ParseClass returns the [Class] with the given name, as returned by [Class.String].
+// Both built-in and registered classes are recognized.
+func ParseClass(name string) (Class, error) {
+	if class, ok := builtin(name); ok {
+		return class, nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if class, ok := registry.byName[name]; ok {
+		return class, nil
+	}
+	return Unknown, fmt.Errorf("errclass: unknown class %q", name)
+}
+
+// builtin returns the built-in class with the given name.
+func builtin(name string) (Class, bool) {
+	switch name {
+	case "nil":
+		return Nil, true
+	case "unknown":
+		return Unknown, true
+	case "transient":
+		return Transient, true
+	case "persistent":
+		return Persistent, true
+	case "panic":
+		return Panic, true
+	}
+	return Unknown, false
+}
+
+// lookup returns the registration of a class created with [Register].
+func lookup(c Class) (registered, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	r, ok := registry.byID[c]
+	return r, ok
+}
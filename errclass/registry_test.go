@@ -0,0 +1,157 @@
+package errclass_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+)
+
+var (
+	rateLimited  = errclass.Register("test_rate_limited", errclass.Transient)
+	invalidInput = errclass.Register("test_invalid_input", errclass.Persistent)
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		class        errclass.Class
+		wantString   string
+		wantSeverity errclass.Class
+	}{
+		{"Nil", errclass.Nil, "nil", errclass.Nil},
+		{"Unknown", errclass.Unknown, "unknown", errclass.Unknown},
+		{"Transient", errclass.Transient, "transient", errclass.Transient},
+		{"Persistent", errclass.Persistent, "persistent", errclass.Persistent},
+		{"Panic", errclass.Panic, "panic", errclass.Panic},
+		{"registered transient", rateLimited, "test_rate_limited", errclass.Transient},
+		{"registered persistent", invalidInput, "test_invalid_input", errclass.Persistent},
+		{"out of range", errclass.Class(99), "unknown", errclass.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.class.String(); got != tt.wantString {
+				t.Errorf("Class(%d).String() = %q, want %q", tt.class, got, tt.wantString)
+			}
+			if got := tt.class.Severity(); got != tt.wantSeverity {
+				t.Errorf("Class(%d).Severity() = %v, want %v", tt.class, got, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{"empty name", func() { errclass.Register("", errclass.Transient) }},
+		{"built-in name", func() { errclass.Register("transient", errclass.Transient) }},
+		{"duplicate name", func() { errclass.Register("test_rate_limited", errclass.Transient) }},
+		{"invalid severity", func() { errclass.Register("test_invalid_severity", errclass.Unknown) }},
+		{"registered severity", func() { errclass.Register("test_registered_severity", rateLimited) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			defer func() {
+				if recover() == nil {
+					t.Error("expected Register to panic")
+				}
+			}()
+			tt.register()
+		})
+	}
+}
+
+func TestRegisteredClassWrapAs(t *testing.T) {
+	t.Parallel()
+
+	err := errclass.WrapAs(errors.New("err"), rateLimited)
+	if got := errclass.GetClass(err); got != rateLimited {
+		t.Errorf("GetClass() = %v, want %v", got, rateLimited)
+	}
+	if got := errclass.GetClass(err).LogValue().Group()[0].Value.String(); got != "test_rate_limited" {
+		t.Errorf("LogValue() class = %q, want test_rate_limited", got)
+	}
+
+	// Same severity as Transient, so not more severe.
+	got := errclass.WrapAs(err, errclass.Transient, errclass.WithOnlyMoreSevere())
+	if gotClass := errclass.GetClass(got); gotClass != rateLimited {
+		t.Errorf("GetClass() = %v, want %v", gotClass, rateLimited)
+	}
+
+	// Persistent severity outranks Transient severity.
+	got = errclass.WrapAs(err, invalidInput, errclass.WithOnlyMoreSevere())
+	if gotClass := errclass.GetClass(got); gotClass != invalidInput {
+		t.Errorf("GetClass() = %v, want %v", gotClass, invalidInput)
+	}
+
+	// Already classified, so left alone.
+	got = errclass.WrapAs(err, invalidInput, errclass.WithOnlyUnknown())
+	if gotClass := errclass.GetClass(got); gotClass != rateLimited {
+		t.Errorf("GetClass() = %v, want %v", gotClass, rateLimited)
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		in      string
+		want    errclass.Class
+		wantErr bool
+	}{
+		{"nil", "nil", errclass.Nil, false},
+		{"unknown", "unknown", errclass.Unknown, false},
+		{"transient", "transient", errclass.Transient, false},
+		{"persistent", "persistent", errclass.Persistent, false},
+		{"panic", "panic", errclass.Panic, false},
+		{"registered", "test_invalid_input", invalidInput, false},
+		{"unrecognized", "bogus", errclass.Unknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := errclass.ParseClass(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseClass(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseClass(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassText(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Classes []errclass.Class `json:"classes"`
+	}
+	in := config{Classes: []errclass.Class{errclass.Transient, rateLimited}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"classes":["transient","test_rate_limited"]}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	var out config
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Classes) != 2 || out.Classes[0] != errclass.Transient || out.Classes[1] != rateLimited {
+		t.Errorf("json.Unmarshal() = %v, want %v", out.Classes, in.Classes)
+	}
+
+	if err := json.Unmarshal([]byte(`{"classes":["bogus"]}`), &out); err == nil {
+		t.Error("expected error for unrecognized class")
+	}
+}
@@ -0,0 +1,160 @@
+// Package errcode attaches stable, machine-readable codes to errors, such as
+// "BILLING_CARD_DECLINED", so that clients and dashboards can key on them
+// rather than on error messages.
+//
+// Codes are defined once in a process-wide catalog with [Register], together
+// with a default message, an [errclass.Class], an HTTP status and a
+// documentation URL. [Wrap] then attaches the code to an error using
+// [xerrors.Extend] and classifies it, so a single definition drives both
+// classification and rendering.
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errclass/httpmap"
+)
+
+// Code is a stable, machine-readable error code. Codes are compared by value,
+// so a code received over the wire can be looked up in the catalog directly.
+type Code string
+
+// Definition describes a [Code] in the catalog.
+type Definition struct {
+	// Code is the code being defined. It must be non-empty and unique.
+	Code Code
+	// Message is the default message of errors created with [New].
+	Message string
+	// Class is the classification applied by [Wrap] and [New].
+	// [errclass.Unknown] leaves errors unclassified.
+	Class errclass.Class
+	// HTTPStatus is the status returned by [HTTPStatus] for errors with the code.
+	// Zero defers to [httpmap.HTTPStatus].
+	HTTPStatus int
+	// DocsURL links to documentation describing the code, if any.
+	DocsURL string
+}
+
+var catalog = struct {
+	mu    sync.RWMutex
+	codes map[Code]Definition
+}{
+	codes: map[Code]Definition{},
+}
+
+// Register adds def to the catalog and returns its code.
+//
+// Register is intended to be called during package initialization:
+//
+//	var CardDeclined = errcode.Register(errcode.Definition{
+//		Code:       "BILLING_CARD_DECLINED",
+//		Message:    "card declined",
+//		Class:      errclass.Persistent,
+//		HTTPStatus: http.StatusPaymentRequired,
+//		DocsURL:    "https://example.com/errors#card-declined",
+//	})
+//
+// Register panics if the code is empty or already registered.
+func Register(def Definition) Code {
+	if def.Code == "" {
+		panic("errcode: Register called with empty code")
+	}
+
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+	if _, ok := catalog.codes[def.Code]; ok {
+		panic(fmt.Sprintf("errcode: Register called twice for %q", def.Code))
+	}
+	catalog.codes[def.Code] = def
+	return def.Code
+}
+
+// Lookup returns the [Definition] of code, or false if code is not registered.
+func Lookup(code Code) (Definition, bool) {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+	def, ok := catalog.codes[code]
+	return def, ok
+}
+
+// All returns the definition of every registered code, sorted by code, for
+// example to publish the catalog or check it in a test.
+func All() []Definition {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+	defs := make([]Definition, 0, len(catalog.codes))
+	for _, code := range slices.Sorted(maps.Keys(catalog.codes)) {
+		defs = append(defs, catalog.codes[code])
+	}
+	return defs
+}
+
+// String returns the code itself.
+func (c Code) String() string {
+	return string(c)
+}
+
+// LogValue implements [slog.LogValuer], returning the code as a grouped slog value.
+// The documentation URL is included if the code is registered with one.
+func (c Code) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("code", string(c))}
+	if def, ok := Lookup(c); ok && def.DocsURL != "" {
+		attrs = append(attrs, slog.String("docs_url", def.DocsURL))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// FingerprintComponent implements [xerrors.Fingerprinter], contributing the code.
+func (c Code) FingerprintComponent() (string, []string) {
+	return "code", []string{string(c)}
+}
+
+// Wrap attaches code to err and classifies it with the code's [Definition.Class]
+// in a single call. If err is nil, it returns nil.
+// The code is always attached; opts restrict only the classification, exactly
+// as they do for [errclass.WrapAs]. Codes that are not registered, or are
+// registered with [errclass.Unknown], leave the class untouched.
+func Wrap(err error, code Code, opts ...errclass.WrapOption) error {
+	if err == nil {
+		return nil
+	}
+	err = xerrors.Extend(code, err)
+	if def, ok := Lookup(code); ok && def.Class != errclass.Unknown {
+		err = errclass.WrapAs(err, def.Class, opts...)
+	}
+	return err
+}
+
+// New returns a new error with the default message of code, wrapped with [Wrap].
+// Codes that are not registered, or have no message, use the code as the message.
+func New(code Code) error {
+	msg := string(code)
+	if def, ok := Lookup(code); ok && def.Message != "" {
+		msg = def.Message
+	}
+	return Wrap(errors.New(msg), code)
+}
+
+// Get extracts the [Code] from err. It returns the empty code if err is nil
+// or does not carry a code. If err has been wrapped with several codes, the
+// outermost is returned.
+func Get(err error) Code {
+	code, _ := xerrors.Extract[Code](err)
+	return code
+}
+
+// HTTPStatus returns the [Definition.HTTPStatus] of err's code if it has one,
+// and otherwise the status from [httpmap.HTTPStatus].
+func HTTPStatus(err error) int {
+	if def, ok := Lookup(Get(err)); ok && def.HTTPStatus != 0 {
+		return def.HTTPStatus
+	}
+	return httpmap.HTTPStatus(err)
+}
@@ -0,0 +1,173 @@
+package errcode_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+)
+
+var (
+	cardDeclined = errcode.Register(errcode.Definition{
+		Code:       "TEST_CARD_DECLINED",
+		Message:    "card declined",
+		Class:      errclass.Persistent,
+		HTTPStatus: http.StatusPaymentRequired,
+		DocsURL:    "https://example.com/errors#card-declined",
+	})
+	gatewayBusy = errcode.Register(errcode.Definition{
+		Code:  "TEST_GATEWAY_BUSY",
+		Class: errclass.Transient,
+	})
+)
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		code      errcode.Code
+		opts      []errclass.WrapOption
+		wantClass errclass.Class
+	}{
+		{"persistent", errors.New("test"), cardDeclined, nil, errclass.Persistent},
+		{"transient", errors.New("test"), gatewayBusy, nil, errclass.Transient},
+		{"unregistered leaves class", errors.New("test"), "TEST_UNREGISTERED", nil, errclass.Unknown},
+		{"options restrict class", errclass.WrapAs(errors.New("test"), errclass.Transient), cardDeclined, []errclass.WrapOption{errclass.WithOnlyUnknown()}, errclass.Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := errcode.Wrap(tt.err, tt.code, tt.opts...)
+			if got := errcode.Get(err); got != tt.code {
+				t.Errorf("Get() = %q, want %q", got, tt.code)
+			}
+			if got := errclass.GetClass(err); got != tt.wantClass {
+				t.Errorf("GetClass() = %v, want %v", got, tt.wantClass)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("expected wrapped error to match original")
+			}
+		})
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	t.Parallel()
+	if err := errcode.Wrap(nil, cardDeclined); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+	if got := errcode.Get(nil); got != "" {
+		t.Errorf("Get(nil) = %q, want empty", got)
+	}
+	if got := errcode.Get(errors.New("test")); got != "" {
+		t.Errorf("Get() = %q, want empty", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	err := errcode.New(cardDeclined)
+	if err.Error() != "card declined" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "card declined")
+	}
+	if got := errcode.Get(err); got != cardDeclined {
+		t.Errorf("Get() = %q, want %q", got, cardDeclined)
+	}
+	// Without a message, the code is used.
+	if got := errcode.New(gatewayBusy).Error(); got != "TEST_GATEWAY_BUSY" {
+		t.Errorf("Error() = %q, want %q", got, "TEST_GATEWAY_BUSY")
+	}
+}
+
+func TestOutermostCodeWins(t *testing.T) {
+	t.Parallel()
+	err := errcode.Wrap(errcode.New(gatewayBusy), cardDeclined)
+	if got := errcode.Get(err); got != cardDeclined {
+		t.Errorf("Get() = %q, want %q", got, cardDeclined)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		def  errcode.Definition
+	}{
+		{"empty code", errcode.Definition{}},
+		{"duplicate", errcode.Definition{Code: cardDeclined}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			errcode.Register(tt.def)
+		})
+	}
+}
+
+func TestLookupAndAll(t *testing.T) {
+	t.Parallel()
+	def, ok := errcode.Lookup(cardDeclined)
+	if !ok || def.Message != "card declined" || def.HTTPStatus != http.StatusPaymentRequired {
+		t.Errorf("Lookup() = %+v, %v", def, ok)
+	}
+	if _, ok := errcode.Lookup("TEST_UNREGISTERED"); ok {
+		t.Error("expected unregistered code to be missing")
+	}
+
+	all := errcode.All()
+	var codes []errcode.Code
+	for _, d := range all {
+		codes = append(codes, d.Code)
+	}
+	if !slices.IsSorted(codes) {
+		t.Errorf("All() not sorted: %v", codes)
+	}
+	if !slices.Contains(codes, cardDeclined) || !slices.Contains(codes, gatewayBusy) {
+		t.Errorf("All() missing registered codes: %v", codes)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"code status", errcode.New(cardDeclined), http.StatusPaymentRequired},
+		{"falls back to class", errcode.New(gatewayBusy), http.StatusServiceUnavailable},
+		{"falls back to kind", errclass.WrapKind(errcode.New(gatewayBusy), errclass.KindNotFound), http.StatusNotFound},
+		{"no code", errors.New("test"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := errcode.HTTPStatus(tt.err); got != tt.want {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(errcode.New(cardDeclined)))
+	want := `"error_detail":{"code":"TEST_CARD_DECLINED","docs_url":"https://example.com/errors#card-declined","class":"persistent"}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in %s", want, buf.String())
+	}
+}
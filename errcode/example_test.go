@@ -0,0 +1,48 @@
+package errcode_test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+)
+
+var userSuspended = errcode.Register(errcode.Definition{
+	Code:       "ACCOUNT_USER_SUSPENDED",
+	Message:    "user suspended",
+	Class:      errclass.Persistent,
+	HTTPStatus: http.StatusForbidden,
+	DocsURL:    "https://example.com/errors#user-suspended",
+})
+
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func ExampleWrap() {
+	err := errcode.Wrap(errors.New("login rejected"), userSuspended)
+	fmt.Println(errcode.Get(err), errclass.GetClass(err), errcode.HTTPStatus(err))
+	newLogger().Error("login failed", xerrors.Log(err))
+	// Output:
+	// ACCOUNT_USER_SUSPENDED persistent 403
+	// {"level":"ERROR","msg":"login failed","error":{"error":"login rejected","error_detail":{"code":"ACCOUNT_USER_SUSPENDED","docs_url":"https://example.com/errors#user-suspended","class":"persistent"}}}
+}
+
+func ExampleNew() {
+	err := errcode.New(userSuspended)
+	fmt.Println(err)
+	// Output:
+	// user suspended
+}
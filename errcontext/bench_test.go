@@ -24,9 +24,9 @@ func BenchmarkAdd_Existing(b *testing.B) {
 	}
 }
 
-// BenchmarkAdd_Existing_Deep measures the in-place mutation path when the
-// existing Context is buried 5 ExtendedError layers deep, exercising the
-// errors.AsType chain walk in Get.
+// BenchmarkAdd_Existing_Deep measures adding a layer when the existing
+// context is buried 5 ExtendedError layers deep, exercising the
+// errors.AsType chain walk in Add.
 func BenchmarkAdd_Existing_Deep(b *testing.B) {
 	base := errcontext.Add(errors.New("base error"), slog.String("user_id", "123"))
 	for range 5 {
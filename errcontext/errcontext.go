@@ -1,14 +1,22 @@
 // Package errcontext attaches structured logging context to errors.
-// It wraps errors with a [Context] map using [xerrors.Extend], enabling
-// downstream callers to extract [slog.Attr] key-value pairs for logging.
+// It wraps errors with layers of context using [xerrors.Extend], enabling
+// downstream callers to extract the merged [Context] of [slog.Attr] key-value
+// pairs for logging. Each call to [Add] attaches a new layer on top of the
+// existing ones, so the context of an existing error is never modified.
 package errcontext
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"maps"
 	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/redact"
+	"github.com/wood-jp/xerrors/stacktrace"
 )
 
 // Context is a map of key-value pairs attached to an error for structured logging.
@@ -27,48 +35,309 @@ func (c Context) Flatten() []slog.Attr {
 // LogValue implements [slog.LogValuer].
 // An empty context returns an empty group. A non-empty context returns a group
 // containing a single "context" attr whose value is a group of the key-value pairs
-// in sorted key order.
+// in sorted key order, subject to the global [Limits]. Values that are
+// [slog.LogValuer]s are resolved first, with any panic recovered.
 func (c Context) LogValue() slog.Value {
 	if len(c) == 0 {
 		return slog.GroupValue()
 	}
-	return slog.GroupValue(slog.Attr{Key: "context", Value: slog.GroupValue(c.Flatten()...)})
+	return slog.GroupValue(slog.Attr{Key: "context", Value: slog.GroupValue(limits.Load().apply(resolveAttrs(c.Flatten()))...)})
+}
+
+// Layered, when set to true, makes [Add] record the function that called it, and
+// makes context render in log output as one group per function rather than a
+// single merged group. See [Layers] to read the layers back directly.
+var Layered atomic.Bool
+
+const (
+	// depth of stack to skip so that the caller of Add (or of a helper that calls add
+	// directly) is recorded rather than add itself.
+	addCallerDepth = 3
+
+	// unknownFunction groups layers added while [Layered] was not set.
+	unknownFunction = "unknown"
+)
+
+// Layer is the context added to an error by a single call to [Add].
+type Layer struct {
+	// Function is the fully-qualified name of the function that called [Add],
+	// or empty if [Layered] was not set at the time.
+	Function string
+	// Attrs are the attrs that were added, in the order given.
+	Attrs []slog.Attr
+}
+
+// layer records the attrs added to an error by a single call to [Add]. Layers
+// are immutable and form a persistent list: each points at the layer it was
+// added on top of, so adding context never copies the context below it. The
+// merged [Context] is built from the list when it is needed.
+type layer struct {
+	attrs    []slog.Attr
+	function string
+	parent   *layer
+
+	resolveOnce   sync.Once
+	resolvedAttrs []slog.Attr
+}
+
+// contextError is the error returned by [Add], carrying a single layer, which is
+// allocated together with it. It also answers [errors.As] for an
+// [xerrors.ExtendedError] of [Context] with the merged context, so that
+// [xerrors.Extract] of a Context keeps working.
+type contextError struct {
+	xerrors.ExtendedError[*layer]
+	layer layer
+}
+
+// As lets [errors.As] find the merged [Context] of e.
+func (e *contextError) As(target any) bool {
+	t, ok := target.(*xerrors.ExtendedError[Context])
+	if !ok {
+		return false
+	}
+	*t, ok = xerrors.Extend(e.Data.context(), e.Unwrap()).(xerrors.ExtendedError[Context])
+	return ok
+}
+
+// outermostLayer returns the layer of the outermost context attached to err,
+// walking the error tree in the same order as [errors.As]. A [Context] attached
+// directly with [xerrors.Extend] is returned as a layer without a parent, since
+// it replaces any context below it.
+func outermostLayer(err error) *layer {
+	for err != nil {
+		switch e := err.(type) {
+		case *contextError:
+			return e.Data
+		case xerrors.ExtendedError[Context]:
+			return &layer{attrs: e.Data.Flatten()}
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if l := outermostLayer(err); l != nil {
+					return l
+				}
+			}
+			return nil
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+// chain returns l and all of its parents, innermost first.
+func (l *layer) chain() []*layer {
+	var chain []*layer
+	for n := l; n != nil; n = n.parent {
+		chain = append(chain, n)
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// context merges the attrs of l and all of its parents into a new [Context].
+// Outer layers take precedence over inner ones (last-entry-wins).
+func (l *layer) context() Context {
+	c := make(Context)
+	for _, n := range l.chain() {
+		for _, attr := range n.attrs {
+			c[attr.Key] = attr.Value
+		}
+	}
+	return c
+}
+
+// resolvedContext is like [layer.context], but uses the cached [layer.resolved]
+// attrs of each layer.
+func (l *layer) resolvedContext() Context {
+	c := make(Context)
+	for _, n := range l.chain() {
+		for _, attr := range n.resolved() {
+			c[attr.Key] = attr.Value
+		}
+	}
+	return c
+}
+
+// lookup returns the value of key in the merged context of l, without building it.
+func (l *layer) lookup(key string) (slog.Value, bool) {
+	for n := l; n != nil; n = n.parent {
+		for _, attr := range slices.Backward(n.attrs) {
+			if attr.Key == key {
+				return attr.Value, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Shadows tells [xerrors.Log] that l, which logs the context merged from all
+// layers below it, supersedes them, as well as a [Context] attached below it.
+func (l *layer) Shadows(data any) bool {
+	switch data.(type) {
+	case *layer, Context:
+		return true
+	}
+	return false
+}
+
+// LogValue implements [slog.LogValuer], returning the merged [Context.LogValue].
+// If [Layered] is set, the "context" group instead holds one group per function
+// that added context, innermost first, each merged with last-entry-wins and
+// subject to the global [Limits] separately.
+// Inner layers are shadowed by this one in the flat log output of [xerrors.Log].
+// Values are resolved once per layer and cached; see [Lazy].
+func (l *layer) LogValue() slog.Value {
+	if !Layered.Load() {
+		return l.resolvedContext().LogValue()
+	}
+
+	var functions []string
+	byFunction := map[string]Context{}
+	for _, n := range l.chain() {
+		function := n.function
+		if function == "" {
+			function = unknownFunction
+		}
+		c, ok := byFunction[function]
+		if !ok {
+			c = Context{}
+			byFunction[function] = c
+			functions = append(functions, function)
+		}
+		for _, attr := range n.resolved() {
+			c[attr.Key] = attr.Value
+		}
+	}
+	lim := limits.Load()
+	groups := make([]slog.Attr, len(functions))
+	for i, function := range functions {
+		groups[i] = slog.Attr{Key: function, Value: slog.GroupValue(lim.apply(byFunction[function].Flatten())...)}
+	}
+	return slog.GroupValue(slog.Attr{Key: "context", Value: slog.GroupValue(groups...)})
 }
 
 // Add attaches the given [slog.Attr] key-value pairs to err as logging context.
-// If err already has a [Context], the existing map is mutated in place (last-entry-wins).
+// If err already has context, a new layer holding the given attrs is attached on
+// top of it, and takes precedence over it (last-entry-wins). The existing context
+// is never modified, so errors sharing a common base do not see each other's
+// context and Add is safe for concurrent use.
 // Returns nil if err is nil, or err unchanged if no attrs are provided.
 func Add(err error, context ...slog.Attr) error {
+	return add(err, 1, context)
+}
+
+// add implements [Add]. skip is the number of frames between the function to
+// record as the layer's provenance and add, so that helpers wrapping add record
+// their own caller.
+func add(err error, skip int, context []slog.Attr) error {
 	if err == nil {
 		return nil
 	} else if len(context) == 0 {
 		return err
 	}
 
-	// If the error already has context, mutate the existing map in place
-	// to avoid adding a redundant wrapper layer.
-	if existing := Get(err); existing != nil {
-		for _, attr := range context {
-			existing[attr.Key] = attr.Value
-		}
-		return err
+	var function string
+	if Layered.Load() {
+		function = stacktrace.Caller(addCallerDepth + skip).Function
 	}
+	return addLayer(err, function, context)
+}
+
+// addLayer wraps err, which must not be nil, in a new layer recording context
+// on top of the outermost context of err.
+func addLayer(err error, function string, context []slog.Attr) error {
+	e := &contextError{layer: layer{attrs: slices.Clone(context), function: function, parent: outermostLayer(err)}}
+	e.ExtendedError, _ = xerrors.Extend(&e.layer, err).(xerrors.ExtendedError[*layer])
+	return e
+}
 
-	newContext := make(Context, len(context))
-	for _, attr := range context {
-		newContext[attr.Key] = attr.Value
+// With returns an [xerrors.Payload] that attaches the given attrs to a sentinel
+// error created by [xerrors.NewSentinel], exactly as [Add] would:
+//
+//	var ErrQuotaExceeded = xerrors.NewSentinel("quota exceeded",
+//		errcontext.With(slog.String("limit", "requests_per_minute")),
+//	)
+//
+// If [Layered] is set, the function that called With is recorded.
+func With(context ...slog.Attr) xerrors.Payload {
+	context = slices.Clone(context)
+	var function string
+	if Layered.Load() {
+		function = stacktrace.Caller(addCallerDepth).Function
+	}
+	return func(err error) error {
+		if err == nil || len(context) == 0 {
+			return err
+		}
+		return addLayer(err, function, context)
 	}
-	return xerrors.Extend(newContext, err)
 }
 
-// Get extracts the [Context] attached to err, or nil if none is present.
+// Get returns the [Context] attached to err, merged from all of its layers, or
+// nil if none is present. The map is built on each call, so modifying it does not
+// affect err. Values are returned as added: [slog.LogValuer]s, including those
+// created by [Lazy], are not resolved.
 func Get(err error) Context {
-	if err == nil {
+	if l := outermostLayer(err); l != nil {
+		return l.context()
+	}
+	return nil
+}
+
+// Layers returns every [Layer] of context attached to err, innermost first,
+// or nil if none is present. A [Context] attached directly with [xerrors.Extend]
+// and then added to with [Add] appears as the first layer, with an empty Function,
+// replacing any layers below it.
+func Layers(err error) []Layer {
+	l := outermostLayer(err)
+	if l == nil {
 		return nil
 	}
+	chain := l.chain()
+	layers := make([]Layer, len(chain))
+	for i, n := range chain {
+		layers[i] = Layer{Function: n.function, Attrs: slices.Clone(n.attrs)}
+	}
+	return layers
+}
+
+// attrsKey is the [context.Context] key under which [WithAttrs] stores attrs.
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx carrying the given attrs in addition to any
+// already stored on ctx by an earlier call. Use [AddFromContext] to copy them
+// onto an error.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing := FromContext(ctx)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
 
-	if context, ok := xerrors.Extract[Context](err); ok {
-		return context
+// FromContext returns the attrs stored on ctx by [WithAttrs], oldest first,
+// or nil if there are none.
+func FromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
 	}
-	return nil
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return slices.Clip(attrs)
+}
+
+// AddFromContext attaches the attrs stored on ctx by [WithAttrs] to err, exactly
+// as [Add] would. Returns nil if err is nil, or err unchanged if ctx carries no attrs.
+func AddFromContext(ctx context.Context, err error) error {
+	return add(err, 1, FromContext(ctx))
+}
+
+// Sensitive marks the value of attr as sensitive, so that it is rendered
+// according to the [redact] policy wherever it is logged or serialized.
+// Use it with [Add] or [WithAttrs]:
+//
+//	err = errcontext.Add(err, errcontext.Sensitive(slog.String("email", email)))
+func Sensitive(attr slog.Attr) slog.Attr {
+	return slog.Attr{Key: attr.Key, Value: redact.Value(attr.Value)}
 }
@@ -1,9 +1,13 @@
 package errcontext_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/wood-jp/xerrors"
@@ -144,33 +148,88 @@ func TestAddContextOverOthers(t *testing.T) {
 	}
 }
 
-// TestAddContextInPlace validates that adding context to an error that
-// already has context mutates the existing map rather than adding a new
-// wrapper layer.
-func TestAddContextInPlace(t *testing.T) {
+// TestAddContextIsolated validates that adding context to an error that
+// already has context leaves the original error's context untouched, so
+// errors sharing a common base do not leak context into each other.
+func TestAddContextIsolated(t *testing.T) {
 	t.Parallel()
 
-	// First Add creates a wrapper
-	err := errcontext.Add(errTest, slog.String("key1", "val1"))
+	base := errcontext.Add(errTest, slog.String("key1", "val1"))
 
-	// Second Add should mutate in place and return the same error
-	err2 := errcontext.Add(err, slog.String("key2", "val2"))
+	err1 := errcontext.Add(base, slog.String("key2", "val2"))
+	err2 := errcontext.Add(base, slog.String("key2", "other"), slog.String("key3", "val3"))
 
-	// Verify both keys are present
-	got := errcontext.Get(err2).Flatten()
-	want := []slog.Attr{slog.String("key1", "val1"), slog.String("key2", "val2")}
+	got := errcontext.Get(base).Flatten()
+	want := []slog.Attr{slog.String("key1", "val1")}
 	if !attrsEqual(got, want) {
-		t.Errorf("expected %v, got %v", want, got)
+		t.Errorf("base: expected %v, got %v", want, got)
 	}
 
-	// Verify there is only one context wrapper layer:
-	// unwrapping once should yield the base error with no context.
-	unwrapped := errors.Unwrap(err2)
-	if _, ok := xerrors.Extract[errcontext.Context](unwrapped); ok {
-		t.Error("expected no context on inner error; found a redundant context layer")
+	got = errcontext.Get(err1).Flatten()
+	want = []slog.Attr{slog.String("key1", "val1"), slog.String("key2", "val2")}
+	if !attrsEqual(got, want) {
+		t.Errorf("err1: expected %v, got %v", want, got)
+	}
+
+	got = errcontext.Get(err2).Flatten()
+	want = []slog.Attr{slog.String("key1", "val1"), slog.String("key2", "other"), slog.String("key3", "val3")}
+	if !attrsEqual(got, want) {
+		t.Errorf("err2: expected %v, got %v", want, got)
+	}
+
+	// Modifying the returned map does not affect the error.
+	errcontext.Get(err1)["key1"] = slog.StringValue("changed")
+	if got := errcontext.Get(err1)["key1"].String(); got != "val1" {
+		t.Errorf("expected key1=val1 after modifying Get result, got %v", got)
+	}
+}
+
+// TestAddContextConcurrent validates that many goroutines can add context to
+// the same base error at once. Run with -race.
+func TestAddContextConcurrent(t *testing.T) {
+	t.Parallel()
+
+	base := errcontext.Add(errTest, slog.String("base", "base"))
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			err := errcontext.Add(base, slog.Int("i", i))
+			if got := errcontext.Get(err)["i"].Int64(); got != int64(i) {
+				t.Errorf("expected i=%d, got %d", i, got)
+			}
+			_ = xerrors.Log(err)
+		})
 	}
-	if !errors.Is(unwrapped, errTest) {
-		t.Error("expected inner error to be errTest")
+	wg.Wait()
+
+	if got := len(errcontext.Get(base)); got != 1 {
+		t.Errorf("expected base context to be unchanged, got %d keys", got)
+	}
+}
+
+// TestAddContextLogOutput validates that multiple layers of context are logged
+// as a single merged "context" group.
+func TestAddContextLogOutput(t *testing.T) {
+	t.Parallel()
+
+	err := errcontext.Add(errTest, slog.String("one", "one"), slog.String("two", "two"))
+	err = errclass.WrapAs(err, errclass.Transient)
+	err = errcontext.Add(err, slog.String("two", "three"))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Error("test", xerrors.Log(err))
+
+	want := `{"level":"ERROR","msg":"test","error":{"error":"this is a test error","error_detail":{"context":{"one":"one","two":"three"},"class":"transient"}}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("expected %s, got %s", want, got)
 	}
 }
 
@@ -270,3 +329,191 @@ func TestGetNoContext(t *testing.T) {
 		t.Error("expected nil context for error without context")
 	}
 }
+
+// repository and handler add context from two different functions.
+func repository() error {
+	return errcontext.Add(errTest, slog.Int("user_id", 42), slog.String("table", "users"))
+}
+
+func handler() error {
+	err := repository()
+	return errcontext.Add(err, slog.Int("user_id", 7), slog.String("request_id", "abc"))
+}
+
+func TestLayered(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	errcontext.Layered.Store(true)
+	t.Cleanup(func() { errcontext.Layered.Store(false) })
+
+	err := handler()
+
+	layers := errcontext.Layers(err)
+	if len(layers) != 2 {
+		t.Fatalf("expected 2 layers, got %d", len(layers))
+	}
+	if got := layers[0].Function; !strings.HasSuffix(got, "errcontext_test.repository") {
+		t.Errorf("layers[0].Function = %q, want errcontext_test.repository", got)
+	}
+	if got := layers[1].Function; !strings.HasSuffix(got, "errcontext_test.handler") {
+		t.Errorf("layers[1].Function = %q, want errcontext_test.handler", got)
+	}
+	want := []slog.Attr{slog.Int("user_id", 42), slog.String("table", "users")}
+	if !attrsEqual(layers[0].Attrs, want) {
+		t.Errorf("layers[0].Attrs: expected %v, got %v", want, layers[0].Attrs)
+	}
+
+	// The merged view is unaffected.
+	if got := errcontext.Get(err)["user_id"].Int64(); got != 7 {
+		t.Errorf("expected merged user_id=7, got %d", got)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("test", xerrors.Log(err))
+	out := buf.String()
+	wantOut := `"context":{"github.com/wood-jp/xerrors/errcontext_test.repository":{"table":"users","user_id":42},` +
+		`"github.com/wood-jp/xerrors/errcontext_test.handler":{"request_id":"abc","user_id":7}}`
+	if !strings.Contains(out, wantOut) {
+		t.Errorf("expected output to contain %s, got %s", wantOut, out)
+	}
+}
+
+func TestLayersNotLayered(t *testing.T) {
+	t.Parallel()
+
+	if got := errcontext.Layers(errTest); got != nil {
+		t.Errorf("expected nil layers for error without context, got %v", got)
+	}
+
+	err := handler()
+	layers := errcontext.Layers(err)
+	if len(layers) != 2 {
+		t.Fatalf("expected 2 layers, got %d", len(layers))
+	}
+	for _, l := range layers {
+		if l.Function != "" {
+			t.Errorf("expected no function recorded, got %q", l.Function)
+		}
+	}
+}
+
+func TestWithAttrs(t *testing.T) {
+	t.Parallel()
+
+	if got := errcontext.FromContext(context.Background()); got != nil {
+		t.Errorf("expected no attrs on background context, got %v", got)
+	}
+	if got := errcontext.AddFromContext(context.Background(), errTest); got != errTest { //nolint:errorlint // intentional identity check: no attrs must return the exact same error
+		t.Error("expected same error returned when context has no attrs")
+	}
+	if got := errcontext.AddFromContext(context.Background(), nil); got != nil {
+		t.Errorf("expected nil for nil error, got %v", got)
+	}
+
+	ctx := errcontext.WithAttrs(context.Background(), slog.String("request_id", "abc"))
+	ctx1 := errcontext.WithAttrs(ctx, slog.String("tenant", "t1"))
+	ctx2 := errcontext.WithAttrs(ctx, slog.String("tenant", "t2"))
+
+	want := []slog.Attr{slog.String("request_id", "abc"), slog.String("tenant", "t1")}
+	if got := errcontext.FromContext(ctx1); !attrsEqual(got, want) {
+		t.Errorf("ctx1: expected %v, got %v", want, got)
+	}
+	want = []slog.Attr{slog.String("request_id", "abc"), slog.String("tenant", "t2")}
+	if got := errcontext.FromContext(ctx2); !attrsEqual(got, want) {
+		t.Errorf("ctx2: expected %v, got %v", want, got)
+	}
+
+	err := errcontext.Add(errTest, slog.Int("user_id", 42))
+	err = errcontext.AddFromContext(ctx1, err)
+	want = []slog.Attr{slog.String("request_id", "abc"), slog.String("tenant", "t1"), slog.Int("user_id", 42)}
+	if got := errcontext.Get(err).Flatten(); !attrsEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	t.Parallel()
+
+	err := errcontext.Add(errTest, errcontext.Sensitive(slog.String("email", "alice@example.com")))
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("test", xerrors.Log(err))
+	if out := buf.String(); strings.Contains(out, "alice@example.com") || !strings.Contains(out, `"email":"[REDACTED]"`) {
+		t.Errorf("expected email to be redacted: %s", out)
+	}
+
+	// Typed keys still read the raw value.
+	email := errcontext.NewKey[string]("email")
+	if got, ok := email.Get(err); !ok || got != "alice@example.com" {
+		t.Errorf("email.Get() = %q, %v, want alice@example.com, true", got, ok)
+	}
+}
+
+func TestWith(t *testing.T) {
+	t.Parallel()
+
+	errQuota := xerrors.NewSentinel("quota exceeded", errcontext.With(slog.String("limit", "rpm")))
+	err := errcontext.Add(fmt.Errorf("calling api: %w", errQuota), slog.Int("attempt", 2))
+
+	if !errors.Is(err, errQuota) {
+		t.Error("expected errors.Is to match the sentinel")
+	}
+	c := errcontext.Get(err)
+	if got := c["limit"].String(); got != "rpm" {
+		t.Errorf("limit = %q, want rpm", got)
+	}
+	if got := c["attempt"].Int64(); got != 2 {
+		t.Errorf("attempt = %d, want 2", got)
+	}
+	// The sentinel's own context is unchanged.
+	if got := len(errcontext.Get(errQuota)); got != 1 {
+		t.Errorf("expected 1 key on the sentinel, got %d", got)
+	}
+}
+
+func TestWithLayered(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	errcontext.Layered.Store(true)
+	t.Cleanup(func() { errcontext.Layered.Store(false) })
+
+	err := xerrors.NewSentinel("test", errcontext.With(slog.String("k", "v")))
+	layers := errcontext.Layers(err)
+	if len(layers) != 1 {
+		t.Fatalf("expected 1 layer, got %d", len(layers))
+	}
+	if got := layers[0].Function; !strings.HasSuffix(got, "errcontext_test.TestWithLayered") {
+		t.Errorf("Function = %q, want errcontext_test.TestWithLayered", got)
+	}
+}
+
+// TestExtractContext validates that the attached payload is a Context, and that a
+// Context attached directly with xerrors.Extend is used by Get, Add and Layers.
+func TestExtractContext(t *testing.T) {
+	t.Parallel()
+
+	err := errcontext.Add(errTest, slog.String("key1", "val1"))
+	got, ok := xerrors.Extract[errcontext.Context](err)
+	if !ok || got["key1"].String() != "val1" {
+		t.Fatalf("Extract() = %v, %v, want key1=val1", got, ok)
+	}
+
+	err = xerrors.Extend(errcontext.Context{"key2": slog.StringValue("val2")}, err)
+	if got := errcontext.Get(err).Flatten(); !attrsEqual(got, []slog.Attr{slog.String("key2", "val2")}) {
+		t.Errorf("Get() = %v, want key2=val2", got)
+	}
+
+	err = errcontext.Add(err, slog.String("key3", "val3"))
+	want := []slog.Attr{slog.String("key2", "val2"), slog.String("key3", "val3")}
+	if got := errcontext.Get(err).Flatten(); !attrsEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+	layers := errcontext.Layers(err)
+	if len(layers) != 2 || !attrsEqual(layers[0].Attrs, []slog.Attr{slog.String("key2", "val2")}) {
+		t.Errorf("Layers() = %v, want the extended context as the first layer", layers)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("test", xerrors.Log(err))
+	if got := buf.String(); !strings.Contains(got, `"context":{"key2":"val2","key3":"val3"}`) {
+		t.Errorf("log output %s does not contain the merged context", got)
+	} else if n := strings.Count(got, `"context"`); n != 1 {
+		t.Errorf("log output %s has %d context groups, want 1", got, n)
+	}
+}
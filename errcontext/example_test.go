@@ -1,7 +1,9 @@
 package errcontext_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 
@@ -30,3 +32,37 @@ func ExampleAdd() {
 	// Output:
 	// {"level":"ERROR","msg":"handler error","error":{"error":"request failed","error_detail":{"context":{"status":503,"user_id":"u123"}}}}
 }
+
+func ExampleAddFromContext() {
+	// Typically done once by request middleware.
+	ctx := errcontext.WithAttrs(context.Background(), slog.String("request_id", "abc"))
+
+	err := errors.New("request failed")
+	err = errcontext.AddFromContext(ctx, err)
+	newLogger().Error("handler error", xerrors.Log(err))
+	// Output:
+	// {"level":"ERROR","msg":"handler error","error":{"error":"request failed","error_detail":{"context":{"request_id":"abc"}}}}
+}
+
+func ExampleKey() {
+	var userID = errcontext.NewKey[int64]("user_id")
+
+	err := userID.Add(errors.New("user not found"), 42)
+	id, ok := userID.Get(err)
+	fmt.Println(id, ok)
+	newLogger().Error("lookup failed", xerrors.Log(err))
+	// Output:
+	// 42 true
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"user not found","error_detail":{"context":{"user_id":42}}}}
+}
+
+func ExampleLazy() {
+	err := errors.New("request failed")
+	err = errcontext.Add(err, errcontext.Lazy("items", func() any {
+		// Only called if the error is logged.
+		return []string{"a", "b"}
+	}))
+	newLogger().Error("handler error", xerrors.Log(err))
+	// Output:
+	// {"level":"ERROR","msg":"handler error","error":{"error":"request failed","error_detail":{"context":{"items":["a","b"]}}}}
+}
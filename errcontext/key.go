@@ -0,0 +1,89 @@
+package errcontext
+
+import (
+	"log/slog"
+	"reflect"
+
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// Key is a typed key for a single [Context] value. Declaring keys once as
+// package-level variables catches typos at compile time and avoids kind switches
+// on [slog.Value] when reading values back:
+//
+//	var UserID = errcontext.NewKey[int64]("user_id")
+//
+//	err = UserID.Add(err, 42)
+//	id, ok := UserID.Get(err)
+//
+// Values are stored in the same [Context] as those added by [Add], so log output
+// is unchanged.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a [Key] for values of type T stored under name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name the key's values are stored under.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Attr returns v as an [slog.Attr] under the key's name, for use with [Add] or [WithAttrs].
+func (k Key[T]) Attr(v T) slog.Attr {
+	return slog.Any(k.name, v)
+}
+
+// Add attaches v to err under the key's name, exactly as [Add] would.
+// Returns nil if err is nil.
+func (k Key[T]) Add(err error, v T) error {
+	return add(err, 1, []slog.Attr{k.Attr(v)})
+}
+
+// Get returns the value stored under the key's name in err's context.
+// It returns false if err has no such value, or if the value is not a T,
+// for example because it was added with [Add] using a different type.
+func (k Key[T]) Get(err error) (T, bool) {
+	var zero T
+	l := outermostLayer(err)
+	if l == nil {
+		return zero, false
+	}
+	val, ok := l.lookup(k.name)
+	if !ok {
+		return zero, false
+	}
+	return valueAs[T](val)
+}
+
+// valueAs converts val back to T, looking through values marked [Sensitive].
+// [slog.AnyValue] stores every signed integer as an int64, every unsigned integer
+// as a uint64, and every float as a float64, so those are converted back to the
+// narrower type T if necessary.
+func valueAs[T any](val slog.Value) (T, bool) {
+	var zero T
+	val = redact.Reveal(val)
+	v := val.Any()
+	if t, ok := v.(T); ok {
+		return t, true
+	}
+
+	target := reflect.TypeFor[T]()
+	var convertible bool
+	switch val.Kind() {
+	case slog.KindInt64:
+		convertible = target.Kind() >= reflect.Int && target.Kind() <= reflect.Int64
+	case slog.KindUint64:
+		convertible = target.Kind() >= reflect.Uint && target.Kind() <= reflect.Uintptr
+	case slog.KindFloat64:
+		convertible = target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64
+	}
+	if !convertible {
+		return zero, false
+	}
+	t, ok := reflect.ValueOf(v).Convert(target).Interface().(T)
+	return t, ok
+}
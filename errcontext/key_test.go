@@ -0,0 +1,117 @@
+package errcontext_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errcontext"
+)
+
+type userID int64
+
+var (
+	keyInt      = errcontext.NewKey[int]("int")
+	keyInt64    = errcontext.NewKey[int64]("int64")
+	keyUint8    = errcontext.NewKey[uint8]("uint8")
+	keyFloat32  = errcontext.NewKey[float32]("float32")
+	keyString   = errcontext.NewKey[string]("string")
+	keyBool     = errcontext.NewKey[bool]("bool")
+	keyDuration = errcontext.NewKey[time.Duration]("duration")
+	keyUserID   = errcontext.NewKey[userID]("user_id")
+)
+
+func TestKeyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	err := keyInt.Add(errTest, 42)
+	err = keyInt64.Add(err, -7)
+	err = keyUint8.Add(err, 200)
+	err = keyFloat32.Add(err, 1.5)
+	err = keyString.Add(err, "hello")
+	err = keyBool.Add(err, true)
+	err = keyDuration.Add(err, time.Second)
+	err = keyUserID.Add(err, 99)
+
+	if got, ok := keyInt.Get(err); !ok || got != 42 {
+		t.Errorf("keyInt.Get() = %v, %v, want 42, true", got, ok)
+	}
+	if got, ok := keyInt64.Get(err); !ok || got != -7 {
+		t.Errorf("keyInt64.Get() = %v, %v, want -7, true", got, ok)
+	}
+	if got, ok := keyUint8.Get(err); !ok || got != 200 {
+		t.Errorf("keyUint8.Get() = %v, %v, want 200, true", got, ok)
+	}
+	if got, ok := keyFloat32.Get(err); !ok || got != 1.5 {
+		t.Errorf("keyFloat32.Get() = %v, %v, want 1.5, true", got, ok)
+	}
+	if got, ok := keyString.Get(err); !ok || got != "hello" {
+		t.Errorf("keyString.Get() = %v, %v, want hello, true", got, ok)
+	}
+	if got, ok := keyBool.Get(err); !ok || !got {
+		t.Errorf("keyBool.Get() = %v, %v, want true, true", got, ok)
+	}
+	if got, ok := keyDuration.Get(err); !ok || got != time.Second {
+		t.Errorf("keyDuration.Get() = %v, %v, want 1s, true", got, ok)
+	}
+	if got, ok := keyUserID.Get(err); !ok || got != 99 {
+		t.Errorf("keyUserID.Get() = %v, %v, want 99, true", got, ok)
+	}
+}
+
+func TestKeyGet(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := keyInt.Get(nil); ok {
+		t.Error("expected false for nil error")
+	}
+	if _, ok := keyInt.Get(errTest); ok {
+		t.Error("expected false for error without context")
+	}
+	if _, ok := keyInt.Get(keyString.Add(errTest, "x")); ok {
+		t.Error("expected false for missing key")
+	}
+
+	// A value added under the same name with a different type does not match.
+	err := errcontext.Add(errTest, slog.String("int", "not a number"))
+	if _, ok := keyInt.Get(err); ok {
+		t.Error("expected false for mismatched type")
+	}
+	err = errcontext.Add(errTest, slog.Int("string", 1))
+	if _, ok := keyString.Get(err); ok {
+		t.Error("expected false for integer read as string")
+	}
+
+	// Values added with Add under the key's name are readable, and the outermost wins.
+	err = errcontext.Add(errTest, slog.Int("int", 1))
+	err = keyInt.Add(err, 2)
+	err = errcontext.Add(err, slog.String("other", "x"))
+	if got, ok := keyInt.Get(err); !ok || got != 2 {
+		t.Errorf("keyInt.Get() = %v, %v, want 2, true", got, ok)
+	}
+
+	if got := keyInt.Add(nil, 1); got != nil {
+		t.Errorf("expected nil for nil error, got %v", got)
+	}
+}
+
+func TestKeyLogOutput(t *testing.T) {
+	t.Parallel()
+
+	typed := keyInt64.Add(errors.New("oops"), 42)
+	untyped := errcontext.Add(errors.New("oops"), slog.Int64("int64", 42))
+
+	var typedBuf, untypedBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&typedBuf, nil)).Info("test", xerrors.Log(typed))
+	slog.New(slog.NewJSONHandler(&untypedBuf, nil)).Info("test", xerrors.Log(untyped))
+
+	// Strip the timestamps before comparing.
+	strip := func(s string) string { return s[strings.Index(s, `"level"`):] }
+	if strip(typedBuf.String()) != strip(untypedBuf.String()) {
+		t.Errorf("typed output %s differs from untyped output %s", typedBuf.String(), untypedBuf.String())
+	}
+}
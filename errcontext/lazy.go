@@ -0,0 +1,85 @@
+package errcontext
+
+import (
+	"log/slog"
+
+	"github.com/wood-jp/xerrors/calm"
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// maxResolveDepth bounds the number of LogValue calls made to resolve a single
+// value, matching [slog.Value.Resolve], so that a LogValuer returning itself
+// cannot loop forever.
+const maxResolveDepth = 100
+
+// lazyValue is the [slog.LogValuer] created by [Lazy].
+type lazyValue func() any
+
+// LogValue implements [slog.LogValuer] by calling the deferred function.
+func (f lazyValue) LogValue() slog.Value {
+	return slog.AnyValue(f())
+}
+
+// Lazy returns an attr whose value is computed by f only if the error it is
+// added to is actually logged, for values that are expensive to build:
+//
+//	err = errcontext.Add(err, errcontext.Lazy("request", func() any { return dump(req) }))
+//
+// f is called at most once per [Add] layer, however many times the error is logged,
+// and a panic in f is recovered and rendered in place of the value. [Get] and
+// [Key.Get] return the deferred value without calling f.
+func Lazy(key string, f func() any) slog.Attr {
+	return slog.Any(key, lazyValue(f))
+}
+
+// resolved returns the attrs of l with every [slog.LogValuer] value resolved by
+// [resolve]. Resolution happens on first use and the result is cached, so each
+// LogValue method is called at most once however many times l is logged.
+func (l *layer) resolved() []slog.Attr {
+	l.resolveOnce.Do(func() {
+		l.resolvedAttrs = resolveAttrs(l.attrs)
+	})
+	return l.resolvedAttrs
+}
+
+// resolveAttrs returns a copy of attrs with every value passed through [resolve].
+func resolveAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		out[i] = slog.Attr{Key: attr.Key, Value: resolve(attr.Value)}
+	}
+	return out
+}
+
+// resolve calls LogValue on v until it is no longer an [slog.LogValuer],
+// recovering any panic with [calm.Unpanic] and returning the panic message as a
+// string value in its place. Values marked with [redact.Value] are left as they
+// are, so that the redaction mode is still chosen when the value is written.
+func resolve(v slog.Value) slog.Value {
+	if !needsResolve(v) {
+		return v
+	}
+	err := calm.Unpanic(func() error {
+		for range maxResolveDepth {
+			v = v.LogValuer().LogValue()
+			if !needsResolve(v) {
+				return nil
+			}
+		}
+		v = slog.StringValue("!ERROR: LogValue called too many times")
+		return nil
+	})
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	return v
+}
+
+// needsResolve reports whether v is an [slog.LogValuer] other than a [redact.Secret].
+func needsResolve(v slog.Value) bool {
+	if v.Kind() != slog.KindLogValuer {
+		return false
+	}
+	_, secret := v.Any().(redact.Secret)
+	return !secret
+}
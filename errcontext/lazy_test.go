@@ -0,0 +1,118 @@
+package errcontext_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// countingValuer counts how many times it is resolved.
+type countingValuer struct{ calls *atomic.Int32 }
+
+func (c countingValuer) LogValue() slog.Value {
+	c.calls.Add(1)
+	return slog.StringValue("expensive")
+}
+
+// panickingValuer panics when resolved.
+type panickingValuer struct{}
+
+func (panickingValuer) LogValue() slog.Value {
+	panic("boom")
+}
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	err := errcontext.Add(errors.New("test"), errcontext.Lazy("dump", func() any {
+		calls.Add(1)
+		return map[string]int{"a": 1}
+	}))
+
+	// Reading the context does not compute the value.
+	if _, ok := errcontext.Get(err)["dump"].Any().(slog.LogValuer); !ok {
+		t.Errorf("expected Get to return the deferred value, got %v", errcontext.Get(err)["dump"])
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("expected no calls before logging, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Error("first", xerrors.Log(err))
+	logger.Error("second", xerrors.Log(err))
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 call after logging twice, got %d", n)
+	}
+	if got := strings.Count(buf.String(), `"dump":{"a":1}`); got != 2 {
+		t.Errorf("expected computed value in both lines, got %s", buf.String())
+	}
+}
+
+func TestLogValuerResolvedOnce(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	base := errcontext.Add(errors.New("test"), slog.Any("v", countingValuer{calls: &calls}))
+	// Errors sharing a layer share its resolved values.
+	a := errcontext.Add(base, slog.String("a", "1"))
+	b := errcontext.Add(base, slog.String("b", "2"))
+
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	for _, err := range []error{base, a, b, a} {
+		logger.Error("failed", xerrors.Log(err))
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 call, got %d", n)
+	}
+}
+
+func TestLogValuerPanic(t *testing.T) {
+	t.Parallel()
+
+	err := errcontext.Add(errors.New("test"),
+		slog.Any("bad", panickingValuer{}),
+		slog.String("good", "ok"),
+	)
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(err))
+	out := buf.String()
+	if !strings.Contains(out, `"bad":"panic: boom"`) {
+		t.Errorf("expected recovered panic in output, got %s", out)
+	}
+	if !strings.Contains(out, `"good":"ok"`) {
+		t.Errorf("expected other values to be kept, got %s", out)
+	}
+
+	// A Context read with Get is protected too.
+	if got := rendered(t, errcontext.Get(err))[0].Value.String(); got != "panic: boom" {
+		t.Errorf("Context.LogValue() bad = %q, want %q", got, "panic: boom")
+	}
+}
+
+func TestLazyKeepsSecrets(t *testing.T) {
+	t.Parallel()
+
+	err := errcontext.Add(errors.New("test"),
+		errcontext.Sensitive(slog.String("email", "alice@example.com")),
+		errcontext.Lazy("user", func() any {
+			return slog.GroupValue(slog.Attr{Key: "email", Value: redact.Value(slog.StringValue("bob@example.com"))})
+		}),
+	)
+	// Resolution must not render secrets early, so that a trusted handler can still reveal them.
+	var buf bytes.Buffer
+	slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), redact.ModeReveal)).Error("failed", xerrors.Log(err))
+	for _, want := range []string{"alice@example.com", "bob@example.com"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in output, got %s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package errcontext
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/wood-jp/xerrors/redact"
+)
+
+const (
+	// TruncatedMarker replaces group values nested deeper than [Limits.MaxDepth],
+	// and is appended to strings cut short by [Limits.MaxStringLen].
+	TruncatedMarker = "[truncated]"
+
+	// DroppedKey is the key of the attr counting how many attrs were dropped
+	// from the rendered context by [Limits.MaxKeys] or [Limits.MaxBytes].
+	DroppedKey = "_dropped"
+)
+
+// Limits bounds the size of context when it is rendered for logging, so that a
+// single oversized value or an unbounded number of keys cannot blow up a log line.
+// Limits are applied at render time: [Get] always returns the full context.
+// A zero value for any field means no limit.
+type Limits struct {
+	// MaxKeys is the maximum number of top-level keys rendered. Keys are kept in
+	// sorted order and the rest are dropped.
+	MaxKeys int
+	// MaxStringLen is the maximum length in bytes of any string value, at any
+	// depth. Longer strings are cut and suffixed with "..." and [TruncatedMarker].
+	// []byte, error and [fmt.Stringer] values are measured by their string form,
+	// and replaced by it, cut the same way, if it is longer. Other values, such as
+	// maps, slices and structs, are left to MaxBytes and MaxDepth. Values marked
+	// [Sensitive] are cut once the redaction policy has chosen how to render them.
+	MaxStringLen int
+	// MaxBytes is the approximate maximum total size in bytes of the rendered keys
+	// and values. Attrs that would exceed it are dropped.
+	MaxBytes int
+	// MaxDepth is the maximum depth of nested groups. Top-level attrs are at
+	// depth 1; group values that would nest deeper are replaced with [TruncatedMarker].
+	MaxDepth int
+}
+
+var limits atomic.Pointer[Limits]
+
+func init() {
+	limits.Store(&Limits{})
+}
+
+// SetLimits replaces the global [Limits]. It is safe for concurrent use, but is
+// intended to be called once during program initialization.
+func SetLimits(l Limits) {
+	limits.Store(&l)
+}
+
+// GetLimits returns the global [Limits].
+func GetLimits() Limits {
+	return *limits.Load()
+}
+
+// apply returns attrs with the limits enforced. If any attrs were dropped, an
+// attr with key [DroppedKey] holding the count is appended.
+func (l *Limits) apply(attrs []slog.Attr) []slog.Attr {
+	if *l == (Limits{}) {
+		return attrs
+	}
+
+	out := make([]slog.Attr, 0, len(attrs))
+	dropped, size := 0, 0
+	for _, attr := range attrs {
+		if l.MaxKeys > 0 && len(out) >= l.MaxKeys {
+			dropped++
+			continue
+		}
+		attr = l.truncate(attr, 1)
+		if l.MaxBytes > 0 {
+			attrSize := len(attr.Key) + valueSize(attr.Value)
+			if size+attrSize > l.MaxBytes {
+				dropped++
+				continue
+			}
+			size += attrSize
+		}
+		out = append(out, attr)
+	}
+	if dropped > 0 {
+		out = append(out, slog.Int(DroppedKey, dropped))
+	}
+	return out
+}
+
+// truncate enforces MaxStringLen and MaxDepth on attr, which is at the given depth.
+func (l *Limits) truncate(attr slog.Attr, depth int) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		if s := attr.Value.String(); l.MaxStringLen > 0 && len(s) > l.MaxStringLen {
+			attr.Value = slog.StringValue(cut(s, l.MaxStringLen) + "..." + TruncatedMarker)
+		}
+	case slog.KindAny:
+		if l.MaxStringLen <= 0 {
+			break
+		}
+		var s string
+		switch v := attr.Value.Any().(type) {
+		case []byte:
+			s = string(v[:min(len(v), l.MaxStringLen+utf8.UTFMax)])
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		}
+		if len(s) > l.MaxStringLen {
+			attr.Value = slog.StringValue(cut(s, l.MaxStringLen) + "..." + TruncatedMarker)
+		}
+	case slog.KindLogValuer:
+		// Sensitive values are rendered when written, according to the redaction
+		// policy, so limit whatever they are rendered as.
+		if _, ok := attr.Value.Any().(redact.Secret); ok {
+			attr.Value = redact.Map(attr.Value, func(v slog.Value) slog.Value {
+				return l.truncate(slog.Attr{Value: resolve(v)}, depth).Value
+			})
+		}
+	case slog.KindGroup:
+		if l.MaxDepth > 0 && depth >= l.MaxDepth {
+			attr.Value = slog.StringValue(TruncatedMarker)
+			break
+		}
+		group := attr.Value.Group()
+		truncated := make([]slog.Attr, len(group))
+		for i, a := range group {
+			truncated[i] = l.truncate(a, depth+1)
+		}
+		attr.Value = slog.GroupValue(truncated...)
+	}
+	return attr
+}
+
+// valueSize returns the approximate size in bytes of v when rendered. Strings
+// and []byte values are measured without formatting them.
+func valueSize(v slog.Value) int {
+	switch v.Kind() {
+	case slog.KindString:
+		return len(v.String())
+	case slog.KindAny:
+		if b, ok := v.Any().([]byte); ok {
+			return len(b)
+		}
+	}
+	return len(v.Resolve().String())
+}
+
+// cut returns the longest prefix of s that is at most n bytes and does not split a rune.
+func cut(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
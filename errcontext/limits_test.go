@@ -0,0 +1,164 @@
+package errcontext_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// setLimits sets the global limits for the duration of the test.
+func setLimits(t *testing.T, l errcontext.Limits) {
+	t.Helper()
+	old := errcontext.GetLimits()
+	errcontext.SetLimits(l)
+	t.Cleanup(func() { errcontext.SetLimits(old) })
+}
+
+// rendered returns the attrs inside the "context" group of c.LogValue().
+func rendered(t *testing.T, c errcontext.Context) []slog.Attr {
+	t.Helper()
+	outer := c.LogValue().Group()
+	if len(outer) != 1 || outer[0].Key != "context" {
+		t.Fatalf("unexpected LogValue: %v", outer)
+	}
+	return outer[0].Value.Group()
+}
+
+func TestLimits(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	c := errcontext.Context{
+		"a": slog.StringValue("short"),
+		"b": slog.StringValue(strings.Repeat("x", 100)),
+		"c": slog.GroupValue(slog.Group("inner", slog.Int("deep", 1)), slog.String("s", strings.Repeat("y", 100))),
+		"d": slog.IntValue(4),
+		"é": slog.StringValue("ééééé"),
+	}
+
+	tests := []struct {
+		name   string
+		limits errcontext.Limits
+		want   string
+	}{
+		{
+			name:   "unlimited",
+			limits: errcontext.Limits{},
+			want:   "[a=short b=" + strings.Repeat("x", 100) + " c=[inner=[deep=1] s=" + strings.Repeat("y", 100) + "] d=4 é=ééééé]",
+		},
+		{
+			name:   "max keys",
+			limits: errcontext.Limits{MaxKeys: 2},
+			want:   "[a=short b=" + strings.Repeat("x", 100) + " _dropped=3]",
+		},
+		{
+			name:   "max string length at any depth without splitting runes",
+			limits: errcontext.Limits{MaxStringLen: 5},
+			want:   "[a=short b=xxxxx...[truncated] c=[inner=[deep=1] s=yyyyy...[truncated]] d=4 é=éé...[truncated]]",
+		},
+		{
+			name:   "max depth",
+			limits: errcontext.Limits{MaxDepth: 2},
+			want:   "[a=short b=" + strings.Repeat("x", 100) + " c=[inner=[truncated] s=" + strings.Repeat("y", 100) + "] d=4 é=ééééé]",
+		},
+		{
+			name:   "max depth of one replaces every group",
+			limits: errcontext.Limits{MaxDepth: 1, MaxKeys: 3},
+			want:   "[a=short b=" + strings.Repeat("x", 100) + " c=[truncated] _dropped=2]",
+		},
+		{
+			name:   "max bytes drops attrs that do not fit",
+			limits: errcontext.Limits{MaxBytes: 20},
+			want:   "[a=short d=4 é=ééééé _dropped=2]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLimits(t, tt.limits)
+			if got := slog.GroupValue(rendered(t, c)...).String(); got != tt.want {
+				t.Errorf("rendered context:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsDoNotAffectGet(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	setLimits(t, errcontext.Limits{MaxKeys: 1, MaxStringLen: 1})
+
+	err := errcontext.Add(errTest, slog.String("a", "long value"), slog.String("b", "other"))
+	c := errcontext.Get(err)
+	if len(c) != 2 || c["a"].String() != "long value" {
+		t.Errorf("expected Get to return the full context, got %v", c)
+	}
+	if got := len(rendered(t, c)); got != 2 {
+		t.Errorf("expected 1 rendered attr plus the dropped count, got %d", got)
+	}
+}
+
+// stringer is a fmt.Stringer with a long string form.
+type stringer struct{}
+
+func (stringer) String() string { return strings.Repeat("s", 100) }
+
+func TestLimitsNonStringValues(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	c := errcontext.Context{
+		"body":     slog.AnyValue([]byte(strings.Repeat("b", 100))),
+		"err":      slog.AnyValue(errors.New(strings.Repeat("e", 100))),
+		"map":      slog.AnyValue(map[string]int{"aaaaaaaaaaaa": 1}),
+		"short":    slog.AnyValue([]byte("ok")),
+		"stringer": slog.AnyValue(stringer{}),
+	}
+
+	t.Run("max string length", func(t *testing.T) {
+		setLimits(t, errcontext.Limits{MaxStringLen: 10})
+		want := "[body=bbbbbbbbbb...[truncated] err=eeeeeeeeee...[truncated] map=map[aaaaaaaaaaaa:1] short=[111 107] stringer=ssssssssss...[truncated]]"
+		attrs := rendered(t, c)
+		if got := slog.GroupValue(attrs...).String(); got != want {
+			t.Errorf("rendered context:\n got %s\nwant %s", got, want)
+		}
+		// Maps, slices and structs keep their structure for JSON handlers.
+		if _, ok := attrs[2].Value.Any().(map[string]int); !ok {
+			t.Errorf("map value = %#v, want the map unchanged", attrs[2].Value.Any())
+		}
+	})
+	t.Run("max bytes", func(t *testing.T) {
+		setLimits(t, errcontext.Limits{MaxBytes: 50})
+		want := "[map=map[aaaaaaaaaaaa:1] short=[111 107] _dropped=3]"
+		if got := slog.GroupValue(rendered(t, c)...).String(); got != want {
+			t.Errorf("rendered context:\n got %s\nwant %s", got, want)
+		}
+	})
+}
+
+func TestLimitsSensitive(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	setLimits(t, errcontext.Limits{MaxStringLen: 10})
+	old := redact.GetPolicy()
+	t.Cleanup(func() { redact.SetPolicy(old) })
+	err := errcontext.Add(errTest, errcontext.Sensitive(slog.String("email", strings.Repeat("a", 100)+"@example.com")))
+	want := `"email":"aaaaaaaaaa...[truncated]"`
+
+	redact.SetPolicy(redact.Policy{Mode: redact.ModeReveal})
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("test", xerrors.Log(err))
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("log output %s does not contain %s", got, want)
+	}
+
+	// The limits also apply when a handler reveals the value.
+	redact.SetPolicy(redact.Policy{})
+	buf.Reset()
+	slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), redact.ModeReveal)).Error("test", xerrors.Log(err))
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("log output %s does not contain %s", got, want)
+	}
+
+	// The placeholder is short enough to be kept as is.
+	buf.Reset()
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("test", xerrors.Log(err))
+	if got, want := buf.String(), `"email":"[REDACTED]"`; !strings.Contains(got, want) {
+		t.Errorf("log output %s does not contain %s", got, want)
+	}
+}
@@ -3,7 +3,8 @@
 // It wraps [golang.org/x/sync/errgroup], with the addition that goroutines
 // launched via [Group.Go] and [Group.TryGo] are wrapped with [calm.Unpanic],
 // so any panic is recovered and returned as an error rather than crashing the
-// program.
+// program. Groups created with [WithContext] also attach the attrs stored on
+// the context by [errcontext.WithAttrs] to every error.
 package errgroup
 
 import (
@@ -12,6 +13,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/wood-jp/xerrors/calm"
+	"github.com/wood-jp/xerrors/errcontext"
 )
 
 // Group is a collection of goroutines working on subtasks that are part of the
@@ -21,6 +23,7 @@ import (
 // not be reused for different tasks.
 type Group struct {
 	group *errgroup.Group
+	ctx   context.Context
 }
 
 // New returns a new Group with no associated context.
@@ -33,9 +36,12 @@ func New() *Group {
 // The derived Context is canceled the first time a function passed to Go
 // returns a non-nil error or the first time Wait returns, whichever occurs
 // first.
+//
+// Errors returned by functions passed to Go carry the attrs stored on ctx by
+// [errcontext.WithAttrs], as if by [errcontext.AddFromContext].
 func WithContext(ctx context.Context) (*Group, context.Context) {
 	group, ctx := errgroup.WithContext(ctx)
-	return &Group{group: group}, ctx
+	return &Group{group: group, ctx: ctx}, ctx
 }
 
 // Go calls the given function in a new goroutine. Panics inside f are
@@ -47,9 +53,7 @@ func WithContext(ctx context.Context) (*Group, context.Context) {
 // Go blocks until the new goroutine can be added without exceeding the
 // configured limit.
 func (g *Group) Go(f func() error) {
-	g.group.Go(func() error {
-		return calm.Unpanic(f)
-	})
+	g.group.Go(g.wrap(f))
 }
 
 // SetLimit limits the number of active goroutines in this group to at most n.
@@ -66,9 +70,19 @@ func (g *Group) SetLimit(n int) {
 // The return value reports whether the goroutine was started. If TryGo would
 // exceed the group's limit, it returns false without calling f.
 func (g *Group) TryGo(f func() error) bool {
-	return g.group.TryGo(func() error {
-		return calm.Unpanic(f)
-	})
+	return g.group.TryGo(g.wrap(f))
+}
+
+// wrap guards f with [calm.Unpanic] and, if the group has a context, attaches
+// the context's attrs to any error.
+func (g *Group) wrap(f func() error) func() error {
+	return func() error {
+		err := calm.Unpanic(f)
+		if g.ctx != nil {
+			err = errcontext.AddFromContext(g.ctx, err)
+		}
+		return err
+	}
 }
 
 // Wait blocks until all function calls from the Go method have returned, then
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"testing"
 
 	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
 	"github.com/wood-jp/xerrors/errgroup"
 )
 
@@ -214,3 +216,41 @@ func TestWithContext(t *testing.T) {
 		}
 	})
 }
+
+func TestWithContextAttrs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		testName      string
+		f             errFunc
+		expectedClass errclass.Class
+	}{
+		{
+			testName:      "error carries context attrs",
+			f:             b,
+			expectedClass: errclass.Unknown,
+		},
+		{
+			testName:      "panic carries context attrs",
+			f:             c,
+			expectedClass: errclass.Panic,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := errcontext.WithAttrs(context.Background(), slog.String("request_id", "abc"))
+			g, _ := errgroup.WithContext(ctx)
+			g.Go(tc.f)
+
+			err := g.Wait()
+			if class := errclass.GetClass(err); class != tc.expectedClass {
+				t.Errorf("unexpected error class: want: %s got %s", tc.expectedClass, class)
+			}
+			if got := errcontext.Get(err)["request_id"].String(); got != "abc" {
+				t.Errorf("unexpected request_id: want abc got %q", got)
+			}
+		})
+	}
+}
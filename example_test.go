@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
 )
 
 func newLogger() *slog.Logger {
@@ -41,3 +42,36 @@ func ExampleExtract() {
 	// Output:
 	// true 503
 }
+
+func ExampleNewSentinel() {
+	errNotFound := xerrors.NewSentinel("not found", xerrors.With(errclass.Persistent))
+
+	err := fmt.Errorf("loading user: %w", errNotFound)
+	fmt.Println(errors.Is(err, errNotFound), errclass.GetClass(err))
+	newLogger().Error("request failed", xerrors.Log(err))
+	// Output:
+	// true persistent
+	// {"level":"ERROR","msg":"request failed","error":{"error":"loading user: not found","error_detail":{"class":"persistent"}}}
+}
+
+func ExampleExtendMsg() {
+	type Query struct{ Table string }
+
+	err := xerrors.ExtendMsg(Query{Table: "users"}, errors.New("connection reset"), "loading user %d", 42)
+	fmt.Println(err)
+	newLogger().Error("request failed", xerrors.Log(err))
+	// Output:
+	// loading user 42: connection reset
+	// {"level":"ERROR","msg":"request failed","error":{"error":"loading user 42: connection reset","error_detail":{"data":{"Table":"users"}}}}
+}
+
+func ExampleFingerprint() {
+	a := errclass.WrapAs(errors.New("user 42 not found"), errclass.Persistent)
+	b := errclass.WrapAs(errors.New("user 7 not found"), errclass.Persistent)
+	c := errclass.WrapAs(errors.New("user 42 not found"), errclass.Transient)
+	fmt.Println(xerrors.Fingerprint(a) == xerrors.Fingerprint(b))
+	fmt.Println(xerrors.Fingerprint(a) == xerrors.Fingerprint(c))
+	// Output:
+	// true
+	// false
+}
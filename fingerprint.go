@@ -0,0 +1,139 @@
+package xerrors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// FingerprintMessage is the name of the normalised message component of [Fingerprint].
+	FingerprintMessage = "message"
+
+	// defaultFingerprintValues is the default limit on the values of each component.
+	defaultFingerprintValues = 5
+)
+
+// Fingerprinter is implemented by payload types that contribute a component to
+// [Fingerprint]. errclass.Class contributes "class", errcode.Code contributes
+// "code" and stacktrace.StackTrace contributes "frames".
+type Fingerprinter interface {
+	// FingerprintComponent returns the name of the component and its values, most
+	// significant first. Values must not vary between occurrences of the same
+	// failure; for example, stack frames contribute function names, not line numbers.
+	FingerprintComponent() (name string, values []string)
+}
+
+type fingerprintOptions struct {
+	exclude   []string
+	maxValues int
+}
+
+// FingerprintOption configures [Fingerprint].
+type FingerprintOption func(opts *fingerprintOptions)
+
+// FingerprintWithout excludes the named components, such as [FingerprintMessage]
+// or "frames", from the fingerprint.
+func FingerprintWithout(components ...string) FingerprintOption {
+	return func(opts *fingerprintOptions) {
+		opts.exclude = append(opts.exclude, components...)
+	}
+}
+
+// FingerprintMaxValues limits each component to its first n values, such as the
+// top n frames of a stack trace. The default is 5; zero or less means no limit.
+func FingerprintMaxValues(n int) FingerprintOption {
+	return func(opts *fingerprintOptions) {
+		opts.maxValues = n
+	}
+}
+
+// normalisers replace the parts of an error message that typically differ
+// between occurrences of the same failure, most specific first.
+var normalisers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// normaliseMessage replaces numbers, long hex strings and UUIDs in msg with placeholders.
+func normaliseMessage(msg string) string {
+	for _, n := range normalisers {
+		msg = n.re.ReplaceAllString(msg, n.repl)
+	}
+	return msg
+}
+
+// Fingerprint returns a stable hash identifying the kind of failure err represents,
+// for grouping and deduplicating errors in alerting. Errors with the same
+// fingerprint have the same normalised message — with numbers, hex strings and
+// UUIDs replaced by placeholders — and the same components contributed by their
+// [Fingerprinter] payloads: by default their class, code and the function names of
+// the top 5 stack frames. When several payloads contribute the same component, the
+// outermost is used, as with [Extract].
+//
+// The fingerprint is 16 hex characters. It returns the empty string if err is nil.
+func Fingerprint(err error, opts ...FingerprintOption) string {
+	if err == nil {
+		return ""
+	}
+	o := fingerprintOptions{maxValues: defaultFingerprintValues}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	components := map[string][]string{
+		FingerprintMessage: {normaliseMessage(err.Error())},
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		ee, ok := e.(extendedErrFlat)
+		if !ok {
+			continue
+		}
+		name, values, ok := ee.fingerprintComponent()
+		if !ok {
+			continue
+		}
+		if _, seen := components[name]; !seen {
+			components[name] = values
+		}
+	}
+
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(components)) {
+		if slices.Contains(o.exclude, name) {
+			continue
+		}
+		values := components[name]
+		if o.maxValues > 0 && len(values) > o.maxValues {
+			values = values[:o.maxValues]
+		}
+		h.Write([]byte(name + "=" + strings.Join(values, "|") + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// logFingerprint holds the options used to add a fingerprint to log output, or
+// nil if it is disabled.
+var logFingerprint atomic.Pointer[[]FingerprintOption]
+
+// SetLogFingerprint controls whether [Log] and [ExtendedError.LogValue] add a
+// "fingerprint" key, computed by [Fingerprint] with opts, next to the "error" key.
+// It is disabled by default. It is safe for concurrent use, but is intended to be
+// called once during program initialization.
+func SetLogFingerprint(enabled bool, opts ...FingerprintOption) {
+	if !enabled {
+		logFingerprint.Store(nil)
+		return
+	}
+	logFingerprint.Store(&opts)
+}
@@ -0,0 +1,23 @@
+package metrics_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/metrics"
+)
+
+// printRecorder prints each observation.
+type printRecorder struct{}
+
+func (printRecorder) Record(l metrics.Labels) {
+	fmt.Printf("class=%s code=%q frame=%q\n", l.Class, l.Code, l.Frame)
+}
+
+func ExampleCounter_Observe() {
+	counter := metrics.New(printRecorder{})
+	counter.Observe(errclass.WrapAs(errors.New("connection reset"), errclass.Transient))
+	// Output:
+	// class=transient code="" frame=""
+}
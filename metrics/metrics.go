@@ -0,0 +1,154 @@
+// Package metrics counts errors by classification. A [Counter] derives a set of
+// [Labels] from each error it observes — its [errclass.Class], [errcode.Code] and
+// the top frame of its [stacktrace.StackTrace] — and passes them to a [Recorder],
+// which typically increments a labelled counter in a metrics system.
+// See the prom subpackage for a Prometheus adapter.
+//
+// Label values derived from errors can be unbounded, so a Counter caps the number
+// of distinct values of each label; see [WithMaxValues].
+package metrics
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+const (
+	// Other replaces label values beyond the limit set by [WithMaxValues].
+	Other = "other"
+
+	// defaultMaxValues is the default limit on distinct values per label.
+	defaultMaxValues = 100
+)
+
+// Labels describes a single observed error.
+type Labels struct {
+	// Class is the name of the error's [errclass.Class].
+	Class string
+	// Code is the error's [errcode.Code], or empty if it has none.
+	Code string
+	// Frame is the function name of the top frame of the error's stack trace
+	// within the module set by [WithModule], or empty if there is none.
+	Frame string
+}
+
+// Recorder records observed errors, for example by incrementing a counter
+// labelled with l. Implementations must be safe for concurrent use.
+type Recorder interface {
+	Record(l Labels)
+}
+
+// Counter observes errors and records them with a [Recorder].
+// A Counter is safe for concurrent use.
+type Counter struct {
+	recorder  Recorder
+	module    string
+	maxValues int
+
+	mu     sync.Mutex
+	codes  map[string]struct{}
+	frames map[string]struct{}
+}
+
+// Option configures a [Counter] created by [New].
+type Option func(c *Counter)
+
+// WithModule restricts the Frame label to frames whose function name starts
+// with prefix, typically the module path, so that errors wrapped inside
+// third-party code are attributed to the first frame of your own code.
+// By default the top frame is used.
+func WithModule(prefix string) Option {
+	return func(c *Counter) {
+		c.module = prefix
+	}
+}
+
+// WithMaxValues sets the maximum number of distinct values of the Code and
+// Frame labels. Once a label has n distinct values, any new value is recorded
+// as [Other]. The default is 100; zero or less means no limit. Class values
+// are bounded by the set of registered classes and are never limited.
+func WithMaxValues(n int) Option {
+	return func(c *Counter) {
+		c.maxValues = n
+	}
+}
+
+// New returns a [Counter] that records errors with recorder.
+func New(recorder Recorder, opts ...Option) *Counter {
+	c := &Counter{
+		recorder:  recorder,
+		maxValues: defaultMaxValues,
+		codes:     map[string]struct{}{},
+		frames:    map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Observe records err. It does nothing if err is nil.
+func (c *Counter) Observe(err error) {
+	if err == nil {
+		return
+	}
+	c.recorder.Record(c.Labels(err))
+}
+
+// Log observes err and returns [xerrors.Log](err), so that errors are counted
+// exactly where they are logged:
+//
+//	logger.Error("request failed", counter.Log(err))
+func (c *Counter) Log(err error) slog.Attr {
+	c.Observe(err)
+	return xerrors.Log(err)
+}
+
+// Labels returns the labels that [Counter.Observe] would record for err,
+// with the cardinality limit applied.
+func (c *Counter) Labels(err error) Labels {
+	l := Labels{
+		Class: errclass.GetClass(err).String(),
+		Code:  string(errcode.Get(err)),
+		Frame: c.frame(stacktrace.Extract(err)),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l.Code = c.guard(c.codes, l.Code)
+	l.Frame = c.guard(c.frames, l.Frame)
+	return l
+}
+
+// frame returns the function of the first frame of st within the module.
+func (c *Counter) frame(st stacktrace.StackTrace) string {
+	for _, f := range st {
+		if strings.HasPrefix(f.Function, c.module) {
+			return f.Function
+		}
+	}
+	return ""
+}
+
+// guard returns value if it has been seen before or there is room for another
+// distinct value in seen, and [Other] otherwise. Empty values are not limited.
+// c.mu must be held.
+func (c *Counter) guard(seen map[string]struct{}, value string) string {
+	if value == "" || c.maxValues <= 0 {
+		return value
+	}
+	if _, ok := seen[value]; ok {
+		return value
+	}
+	if len(seen) >= c.maxValues {
+		return Other
+	}
+	seen[value] = struct{}{}
+	return value
+}
@@ -0,0 +1,144 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/metrics"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+var errTest = errors.New("this is a test error")
+
+var testCode = errcode.Register(errcode.Definition{Code: "TEST_METRICS", Class: errclass.Persistent})
+
+// fakeRecorder collects recorded labels.
+type fakeRecorder struct {
+	mu     sync.Mutex
+	labels []metrics.Labels
+}
+
+func (r *fakeRecorder) Record(l metrics.Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.labels = append(r.labels, l)
+}
+
+func wrapHere(err error) error {
+	return stacktrace.Wrap(err)
+}
+
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		opts []metrics.Option
+		want metrics.Labels
+	}{
+		{"plain", errTest, nil, metrics.Labels{Class: "unknown"}},
+		{"class and code", errcode.Wrap(errTest, testCode), nil, metrics.Labels{Class: "persistent", Code: "TEST_METRICS"}},
+		{"top frame", wrapHere(errTest), nil, metrics.Labels{Class: "unknown", Frame: "github.com/wood-jp/xerrors/metrics_test.wrapHere"}},
+		{
+			"module frame",
+			wrapHere(errTest),
+			[]metrics.Option{metrics.WithModule("github.com/wood-jp/xerrors/metrics_test.TestLabels")},
+			metrics.Labels{Class: "unknown", Frame: "github.com/wood-jp/xerrors/metrics_test.TestLabels"},
+		},
+		{"no frame in module", wrapHere(errTest), []metrics.Option{metrics.WithModule("example.com/")}, metrics.Labels{Class: "unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := metrics.New(&fakeRecorder{}, tt.opts...).Labels(tt.err); got != tt.want {
+				t.Errorf("Labels() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserve(t *testing.T) {
+	t.Parallel()
+
+	rec := &fakeRecorder{}
+	c := metrics.New(rec)
+	c.Observe(nil)
+	c.Observe(errTest)
+	c.Observe(errclass.WrapAs(errTest, errclass.Transient))
+	if len(rec.labels) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(rec.labels))
+	}
+	if rec.labels[1].Class != "transient" {
+		t.Errorf("Class = %q, want transient", rec.labels[1].Class)
+	}
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	rec := &fakeRecorder{}
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", metrics.New(rec).Log(errTest))
+	if len(rec.labels) != 1 {
+		t.Errorf("expected 1 record, got %d", len(rec.labels))
+	}
+	if want := `"error":{"error":"this is a test error"}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in %s", want, buf.String())
+	}
+}
+
+func TestMaxValues(t *testing.T) {
+	t.Parallel()
+
+	codes := []errcode.Code{"TEST_A", "TEST_B", "TEST_C", "TEST_A"}
+	c := metrics.New(&fakeRecorder{}, metrics.WithMaxValues(2))
+	var got []string
+	for _, code := range codes {
+		got = append(got, c.Labels(errcode.Wrap(errTest, code)).Code)
+	}
+	want := []string{"TEST_A", "TEST_B", metrics.Other, "TEST_A"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("codes = %v, want %v", got, want)
+	}
+
+	// Empty values do not count towards the limit.
+	if l := c.Labels(errTest); l.Code != "" {
+		t.Errorf("Code = %q, want empty", l.Code)
+	}
+
+	unlimited := metrics.New(&fakeRecorder{}, metrics.WithMaxValues(0))
+	for _, code := range codes {
+		if l := unlimited.Labels(errcode.Wrap(errTest, code)); l.Code != string(code) {
+			t.Errorf("Code = %q, want %q", l.Code, code)
+		}
+	}
+}
+
+func TestObserveConcurrent(t *testing.T) {
+	t.Parallel()
+
+	rec := &fakeRecorder{}
+	c := metrics.New(rec, metrics.WithMaxValues(5))
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			c.Observe(errcode.Wrap(errTest, errcode.Code(strings.Repeat("X", i%10+1))))
+		})
+	}
+	wg.Wait()
+
+	distinct := map[string]bool{}
+	for _, l := range rec.labels {
+		distinct[l.Code] = true
+	}
+	if len(rec.labels) != 50 || len(distinct) != 6 {
+		t.Errorf("expected 50 records with 5 codes plus %q, got %d records with %v", metrics.Other, len(rec.labels), distinct)
+	}
+}
@@ -0,0 +1,27 @@
+package prom_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/metrics"
+	"github.com/wood-jp/xerrors/metrics/prom"
+)
+
+func ExampleNewRecorder() {
+	rec := prom.NewRecorder(prometheus.CounterOpts{Namespace: "myapp"})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(rec)
+
+	counter := metrics.New(rec, metrics.WithModule("example.com/myapp"))
+	counter.Observe(errclass.WrapAs(errors.New("connection reset"), errclass.Transient))
+
+	n, _ := testutil.GatherAndCount(reg, "myapp_errors_total")
+	fmt.Println(n)
+	// Output:
+	// 1
+}
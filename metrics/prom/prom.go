@@ -0,0 +1,56 @@
+// Package prom adapts [metrics.Recorder] to a Prometheus counter.
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/wood-jp/xerrors/metrics"
+)
+
+// Label names of the counter.
+const (
+	ClassLabel = "class"
+	CodeLabel  = "code"
+	FrameLabel = "frame"
+)
+
+// Recorder is a [metrics.Recorder] that increments a Prometheus counter labelled
+// with [ClassLabel], [CodeLabel] and [FrameLabel]. It implements
+// [prometheus.Collector] and must be registered to be exported:
+//
+//	rec := prom.NewRecorder(prometheus.CounterOpts{Namespace: "myapp"})
+//	prometheus.MustRegister(rec)
+//	counter := metrics.New(rec, metrics.WithModule("example.com/myapp"))
+type Recorder struct {
+	vec *prometheus.CounterVec
+}
+
+// NewRecorder returns a [Recorder] for a counter created with opts.
+// If opts.Name is empty it defaults to "errors_total", and if opts.Help is
+// empty a default description is used.
+func NewRecorder(opts prometheus.CounterOpts) *Recorder {
+	if opts.Name == "" {
+		opts.Name = "errors_total"
+	}
+	if opts.Help == "" {
+		opts.Help = "Number of errors observed, by class, code and top stack frame."
+	}
+	return &Recorder{
+		vec: prometheus.NewCounterVec(opts, []string{ClassLabel, CodeLabel, FrameLabel}),
+	}
+}
+
+// Record implements [metrics.Recorder].
+func (r *Recorder) Record(l metrics.Labels) {
+	r.vec.WithLabelValues(l.Class, l.Code, l.Frame).Inc()
+}
+
+// Describe implements [prometheus.Collector].
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.vec.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector].
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.vec.Collect(ch)
+}
@@ -0,0 +1,53 @@
+package prom_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/metrics"
+	"github.com/wood-jp/xerrors/metrics/prom"
+)
+
+var errTest = errors.New("this is a test error")
+
+var testCode = errcode.Register(errcode.Definition{Code: "TEST_PROM", Class: errclass.Persistent})
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	rec := prom.NewRecorder(prometheus.CounterOpts{Namespace: "test"})
+	counter := metrics.New(rec)
+	counter.Observe(errcode.New(testCode))
+	counter.Observe(errcode.New(testCode))
+	counter.Observe(errclass.WrapAs(errTest, errclass.Transient))
+
+	want := `
+# HELP test_errors_total Number of errors observed, by class, code and top stack frame.
+# TYPE test_errors_total counter
+test_errors_total{class="persistent",code="TEST_PROM",frame=""} 2
+test_errors_total{class="transient",code="",frame=""} 1
+`
+	if err := testutil.CollectAndCompare(rec, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecorderRegister(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	rec := prom.NewRecorder(prometheus.CounterOpts{Name: "failures_total", Help: "Failures."})
+	if err := reg.Register(rec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metrics.New(rec).Observe(errTest)
+	if n, err := testutil.GatherAndCount(reg, "failures_total"); err != nil || n != 1 {
+		t.Errorf("GatherAndCount() = %d, %v, want 1", n, err)
+	}
+}
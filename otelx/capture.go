@@ -0,0 +1,53 @@
+package otelx
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wood-jp/xerrors"
+)
+
+// captured is the payload attached by [Capture].
+type captured struct {
+	sc trace.SpanContext
+}
+
+// LogValue implements [slog.LogValuer], returning the trace and span IDs as a
+// grouped slog value.
+func (c captured) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("trace_id", c.sc.TraceID().String()),
+		slog.String("span_id", c.sc.SpanID().String()),
+	)
+}
+
+// Capture attaches the trace and span IDs of the span active in ctx to err, so
+// that wherever err is logged with [xerrors.Log] it can be linked to its trace
+// as "trace_id" and "span_id", even after crossing goroutines, for example
+// through an errgroup. If err is nil, it returns nil.
+//
+// Like [stacktrace.Wrap], Capture keeps the IDs of the span where the error
+// was first captured: if err already carries IDs, or ctx has no valid span,
+// err is returned unchanged.
+func Capture(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return err
+	}
+	if _, ok := xerrors.Extract[captured](err); ok {
+		return err
+	}
+	return xerrors.Extend(captured{sc: sc}, err)
+}
+
+// SpanContext returns the span context attached to err by [Capture], or false
+// if none is present.
+func SpanContext(err error) (trace.SpanContext, bool) {
+	c, ok := xerrors.Extract[captured](err)
+	return c.sc, ok
+}
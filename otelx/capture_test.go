@@ -0,0 +1,78 @@
+package otelx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errgroup"
+	"github.com/wood-jp/xerrors/otelx"
+)
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	tp := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+	ctx, span := tp.Tracer("test").Start(t.Context(), "outer")
+	defer span.End()
+	sc := span.SpanContext()
+
+	if err := otelx.Capture(ctx, nil); err != nil {
+		t.Errorf("Capture(nil) = %v, want nil", err)
+	}
+	if err := otelx.Capture(context.Background(), errTest); err != errTest { //nolint:errorlint // intentional identity check: err must be returned unchanged
+		t.Error("expected err unchanged without a span")
+	}
+	if _, ok := otelx.SpanContext(errTest); ok {
+		t.Error("expected no span context on a plain error")
+	}
+
+	err := otelx.Capture(ctx, errTest)
+	if !errors.Is(err, errTest) {
+		t.Error("expected errors.Is to match")
+	}
+	if got, ok := otelx.SpanContext(err); !ok || !got.Equal(sc) {
+		t.Errorf("SpanContext() = %v, %v, want %v", got, ok, sc)
+	}
+
+	// The first capture wins.
+	innerCtx, inner := tp.Tracer("test").Start(ctx, "inner")
+	defer inner.End()
+	if got, _ := otelx.SpanContext(otelx.Capture(innerCtx, err)); !got.Equal(sc) {
+		t.Errorf("expected the original span to be kept, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(err))
+	want := `"error_detail":{"trace_id":"` + sc.TraceID().String() + `","span_id":"` + sc.SpanID().String() + `"}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in %s", want, buf.String())
+	}
+}
+
+func TestCaptureAcrossGoroutines(t *testing.T) {
+	t.Parallel()
+
+	tp := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+
+	g := errgroup.New()
+	var spanID string
+	g.Go(func() error {
+		ctx, span := tp.Tracer("test").Start(t.Context(), "worker")
+		defer span.End()
+		spanID = span.SpanContext().SpanID().String()
+		return otelx.Capture(ctx, errTest)
+	})
+	err := g.Wait()
+	if got, ok := otelx.SpanContext(err); !ok || got.SpanID().String() != spanID {
+		t.Errorf("SpanContext() = %v, %v, want span %s", got, ok, spanID)
+	}
+}
@@ -0,0 +1,53 @@
+package otelx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/otelx"
+)
+
+func ExampleRecordError() {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	_, span := tp.Tracer("example").Start(context.Background(), "load user")
+	err := errclass.WrapAs(errors.New("user not found"), errclass.Persistent)
+	err = errcontext.Add(err, slog.Int("user_id", 42))
+	otelx.RecordError(span, err)
+	span.End()
+
+	recorded := exporter.GetSpans()[0]
+	fmt.Println(recorded.Status.Code)
+	for _, kv := range recorded.Events[0].Attributes {
+		fmt.Printf("%s=%s\n", kv.Key, kv.Value.Emit())
+	}
+	// Output:
+	// Error
+	// exception.message=user not found
+	// exception.type=*errors.errorString
+	// error.class=persistent
+	// error.context.user_id=42
+}
+
+func ExampleCapture() {
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ctx, span := tp.Tracer("example").Start(context.Background(), "load user")
+	defer span.End()
+
+	err := otelx.Capture(ctx, errors.New("user not found"))
+	sc, ok := otelx.SpanContext(err)
+	fmt.Println(ok, sc.TraceID() == span.SpanContext().TraceID())
+	// Output:
+	// true true
+}
@@ -0,0 +1,25 @@
+module github.com/wood-jp/xerrors/otelx
+
+go 1.26.1
+
+require (
+	github.com/wood-jp/xerrors v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.44.0
+	go.opentelemetry.io/otel/sdk v1.44.0
+	go.opentelemetry.io/otel/trace v1.44.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.44.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+// The core module is developed alongside this one; see "Nested modules" in
+// CONTRIBUTING.md for how releases pin it.
+replace github.com/wood-jp/xerrors => ../
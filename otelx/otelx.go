@@ -0,0 +1,168 @@
+// Package otelx records errors onto OpenTelemetry spans. [RecordError] adds an
+// "exception" event carrying the error's message, type and [stacktrace.StackTrace],
+// together with its [errclass.Class], [errclass.Kind], [errcode.Code] and
+// [errcontext] keys as attributes, and sets the span status according to the
+// error's class.
+package otelx
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+// Attribute keys set on the exception event in addition to the semantic
+// convention exception attributes.
+const (
+	// ClassKey holds the name of the error's [errclass.Class].
+	ClassKey = attribute.Key("error.class")
+	// KindKey holds the name of the error's [errclass.Kind], if it has one.
+	KindKey = attribute.Key("error.kind")
+	// CodeKey holds the error's [errcode.Code], if it has one.
+	CodeKey = attribute.Key("error.code")
+	// ContextPrefix prefixes the key of every [errcontext] value. Values in
+	// groups are flattened into dotted keys, e.g. "error.context.user.id".
+	ContextPrefix = "error.context."
+)
+
+type options struct {
+	status map[errclass.Class]codes.Code
+	attrs  []attribute.KeyValue
+}
+
+// Option configures [RecordError].
+type Option func(opts *options)
+
+// WithStatus sets the span status used for errors of the given class, overriding
+// the default. [codes.Unset] leaves the span status unchanged.
+func WithStatus(class errclass.Class, code codes.Code) Option {
+	return func(opts *options) {
+		opts.status[class] = code
+	}
+}
+
+// WithAttributes adds attrs to the exception event.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(opts *options) {
+		opts.attrs = append(opts.attrs, attrs...)
+	}
+}
+
+// RecordError records err on span as an "exception" event and sets the span status
+// according to the error's class. It does nothing if err is nil or span is not recording.
+//
+// The event carries:
+//   - exception.message: err.Error()
+//   - exception.type: the Go type of the innermost wrapped error
+//   - exception.stacktrace: the [stacktrace.StackTrace] attached to err, if any
+//   - [ClassKey], [KindKey] and [CodeKey]
+//   - every [errcontext] value, under [ContextPrefix]
+//
+// Context values are rendered as they would be logged, so values marked
+// [errcontext.Sensitive] or matched by the [redact] policy are redacted.
+//
+// By default the status is set to [codes.Error] for every class except
+// [errclass.Transient], which leaves the status unchanged, since transient errors
+// are usually retried. Classes created with [errclass.Register] use the status of
+// their [errclass.Class.Severity] unless set explicitly with [WithStatus].
+func RecordError(span trace.Span, err error, opts ...Option) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	o := options{
+		status: map[errclass.Class]codes.Code{
+			errclass.Unknown:    codes.Error,
+			errclass.Transient:  codes.Unset,
+			errclass.Persistent: codes.Error,
+			errclass.Panic:      codes.Error,
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	class := errclass.GetClass(err)
+	attrs := []attribute.KeyValue{
+		semconv.ExceptionMessage(err.Error()),
+		semconv.ExceptionType(typeName(err)),
+		ClassKey.String(class.String()),
+	}
+	if st := stacktrace.Extract(err); st != nil {
+		attrs = append(attrs, semconv.ExceptionStacktrace(formatStack(st)))
+	}
+	if kind := errclass.GetKind(err); kind != errclass.KindUnknown {
+		attrs = append(attrs, KindKey.String(kind.String()))
+	}
+	if code := errcode.Get(err); code != "" {
+		attrs = append(attrs, CodeKey.String(code.String()))
+	}
+	if c := errcontext.Get(err); c != nil {
+		attrs = appendAttrs(attrs, ContextPrefix, redact.Attrs(c.Flatten()))
+	}
+	attrs = append(attrs, o.attrs...)
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(attrs...))
+
+	status, ok := o.status[class]
+	if !ok {
+		status = o.status[class.Severity()]
+	}
+	if status != codes.Unset {
+		span.SetStatus(status, err.Error())
+	}
+}
+
+// typeName returns the Go type of the innermost error in err's chain, which is
+// more useful than the type of the outermost wrapper. Errors wrapping several
+// errors are not unwrapped further.
+func typeName(err error) string {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+// formatStack renders st in the style of a Go panic trace, one frame per two lines.
+func formatStack(st stacktrace.StackTrace) string {
+	var b strings.Builder
+	for _, frame := range st {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.LineNumber)
+	}
+	return b.String()
+}
+
+// appendAttrs converts slog attrs to attributes with prefixed keys, flattening groups.
+func appendAttrs(attrs []attribute.KeyValue, prefix string, slogAttrs []slog.Attr) []attribute.KeyValue {
+	for _, a := range slogAttrs {
+		key := prefix + a.Key
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindGroup:
+			attrs = appendAttrs(attrs, key+".", v.Group())
+		case slog.KindBool:
+			attrs = append(attrs, attribute.Bool(key, v.Bool()))
+		case slog.KindInt64:
+			attrs = append(attrs, attribute.Int64(key, v.Int64()))
+		case slog.KindFloat64:
+			attrs = append(attrs, attribute.Float64(key, v.Float64()))
+		default:
+			attrs = append(attrs, attribute.String(key, v.String()))
+		}
+	}
+	return attrs
+}
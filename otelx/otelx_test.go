@@ -0,0 +1,142 @@
+package otelx_test
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/otelx"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+var errTest = errors.New("this is a test error")
+
+var (
+	testCode   = errcode.Register(errcode.Definition{Code: "TEST_OTELX", Class: errclass.Persistent})
+	registered = errclass.Register("test_otelx_transient", errclass.Transient)
+)
+
+// record records err on a new span with opts and returns the exported span.
+func record(t *testing.T, err error, opts ...otelx.Option) tracetest.SpanStub {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+
+	_, span := tp.Tracer("test").Start(t.Context(), "op")
+	otelx.RecordError(span, err, opts...)
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	return spans[0]
+}
+
+// eventAttrs returns the attributes of the single exception event on span.
+func eventAttrs(t *testing.T, span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	t.Helper()
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Fatalf("expected a single exception event, got %v", span.Events)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Events[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestRecordError(t *testing.T) {
+	t.Parallel()
+
+	err := stacktrace.Wrap(errTest)
+	err = errclass.WrapKind(err, errclass.KindNotFound)
+	err = errcode.Wrap(err, testCode)
+	err = errcontext.Add(err,
+		slog.String("user", "alice"),
+		slog.Int("attempt", 3),
+		slog.Bool("cached", false),
+		slog.Group("req", slog.String("id", "abc")),
+		errcontext.Sensitive(slog.String("email", "alice@example.com")),
+	)
+
+	span := record(t, err, otelx.WithAttributes(attribute.String("extra", "x")))
+	attrs := eventAttrs(t, span)
+
+	want := map[attribute.Key]attribute.Value{
+		"exception.message":     attribute.StringValue(errTest.Error()),
+		"exception.type":        attribute.StringValue("*errors.errorString"),
+		otelx.ClassKey:          attribute.StringValue("persistent"),
+		otelx.KindKey:           attribute.StringValue("not_found"),
+		otelx.CodeKey:           attribute.StringValue("TEST_OTELX"),
+		"error.context.user":    attribute.StringValue("alice"),
+		"error.context.attempt": attribute.Int64Value(3),
+		"error.context.cached":  attribute.BoolValue(false),
+		"error.context.req.id":  attribute.StringValue("abc"),
+		"error.context.email":   attribute.StringValue("[REDACTED]"),
+		"extra":                 attribute.StringValue("x"),
+	}
+	for key, w := range want {
+		if got, ok := attrs[key]; !ok || got != w {
+			t.Errorf("%s = %v, want %v", key, got.Emit(), w.Emit())
+		}
+	}
+	if st := attrs["exception.stacktrace"].AsString(); !strings.Contains(st, "otelx_test.TestRecordError\n\t") {
+		t.Errorf("unexpected stacktrace: %q", st)
+	}
+	if span.Status.Code != codes.Error || span.Status.Description != errTest.Error() {
+		t.Errorf("status = %v, want Error", span.Status)
+	}
+}
+
+func TestRecordErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		opts []otelx.Option
+		want codes.Code
+	}{
+		{"unknown", errTest, nil, codes.Error},
+		{"transient", errclass.WrapAs(errTest, errclass.Transient), nil, codes.Unset},
+		{"panic", errclass.WrapAs(errTest, errclass.Panic), nil, codes.Error},
+		{"registered falls back to severity", errclass.WrapAs(errTest, registered), nil, codes.Unset},
+		{"override", errclass.WrapAs(errTest, errclass.Transient), []otelx.Option{otelx.WithStatus(errclass.Transient, codes.Error)}, codes.Error},
+		{"override registered", errclass.WrapAs(errTest, registered), []otelx.Option{otelx.WithStatus(registered, codes.Error)}, codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			span := record(t, tt.err, tt.opts...)
+			if span.Status.Code != tt.want {
+				t.Errorf("status = %v, want %v", span.Status.Code, tt.want)
+			}
+			// The event is recorded regardless of status.
+			eventAttrs(t, span)
+		})
+	}
+}
+
+func TestRecordErrorNoop(t *testing.T) {
+	t.Parallel()
+
+	span := record(t, nil)
+	if len(span.Events) != 0 || span.Status.Code != codes.Unset {
+		t.Errorf("expected nil error to be ignored, got %v %v", span.Events, span.Status)
+	}
+
+	// Non-recording spans are ignored without panicking.
+	otelx.RecordError(trace.SpanFromContext(t.Context()), errTest)
+}
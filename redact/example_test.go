@@ -0,0 +1,42 @@
+package redact_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
+)
+
+func newHandler() slog.Handler {
+	return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func ExampleValue() {
+	err := errcontext.Add(errors.New("signup failed"),
+		errcontext.Sensitive(slog.String("email", "alice@example.com")),
+		slog.String("plan", "pro"),
+	)
+	slog.New(newHandler()).Error("request failed", xerrors.Log(err))
+	// Output:
+	// {"level":"ERROR","msg":"request failed","error":{"error":"signup failed","error_detail":{"context":{"email":"[REDACTED]","plan":"pro"}}}}
+}
+
+func ExampleNewHandler() {
+	err := errcontext.Add(errors.New("signup failed"),
+		errcontext.Sensitive(slog.String("email", "alice@example.com")),
+	)
+	trusted := slog.New(redact.NewHandler(newHandler(), redact.ModeReveal))
+	trusted.Error("request failed", xerrors.Log(err))
+	// Output:
+	// {"level":"ERROR","msg":"request failed","error":{"error":"signup failed","error_detail":{"context":{"email":"alice@example.com"}}}}
+}
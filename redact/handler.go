@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+)
+
+// handler is an [slog.Handler] that renders sensitive values with its own [Mode].
+type handler struct {
+	next slog.Handler
+	mode Mode
+}
+
+// NewHandler returns an [slog.Handler] that passes records to next with every
+// sensitive value rendered according to mode rather than the global [Policy].
+// Use it to reveal (or hash) sensitive values for trusted sinks only, while the
+// global policy keeps them out of every other log.
+//
+// Values matched only by the global policy's key patterns are still marked when
+// errors are logged through [xerrors.Log], so they are rendered by mode too.
+// Under [ModeHash], values are hashed with the global [Policy.HashKey], or
+// replaced with [Placeholder] if it is not set.
+func NewHandler(next slog.Handler, mode Mode) slog.Handler {
+	return &handler{next: next, mode: mode}
+}
+
+// Enabled implements [slog.Handler].
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements [slog.Handler].
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		out.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	rendered := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		rendered[i] = h.attr(attr)
+	}
+	return &handler{next: h.next.WithAttrs(rendered), mode: h.mode}
+}
+
+// WithGroup implements [slog.Handler].
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), mode: h.mode}
+}
+
+// attr renders every [Secret] within attr, resolving other values on the way
+// so that secrets nested inside [slog.LogValuer] groups are found.
+func (h *handler) attr(attr slog.Attr) slog.Attr {
+	attr.Value = h.value(attr.Value)
+	return attr
+}
+
+func (h *handler) value(v slog.Value) slog.Value {
+	if s, ok := v.Any().(Secret); ok {
+		v = s.render(h.mode, policy.Load().HashKey)
+	}
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v
+	}
+	group := v.Group()
+	rendered := make([]slog.Attr, len(group))
+	for i, attr := range group {
+		rendered[i] = h.attr(attr)
+	}
+	return slog.GroupValue(rendered...)
+}
@@ -0,0 +1,85 @@
+package redact_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// userInfo logs as a group containing a sensitive value, to check that
+// secrets nested inside an slog.LogValuer are found by the handler.
+type userInfo struct{ email string }
+
+func (u userInfo) LogValue() slog.Value {
+	return slog.GroupValue(slog.Attr{Key: "email", Value: redact.Value(slog.StringValue(u.email))})
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mode redact.Mode
+		want string
+		deny string
+	}{
+		{"reveal", redact.ModeReveal, "alice@example.com", redact.Placeholder},
+		{"redact", redact.ModeRedact, redact.Placeholder, "alice@example.com"},
+		// The global policy has no hash key, so nothing can be hashed.
+		{"hash without key", redact.ModeHash, redact.Placeholder, "alice@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), tt.mode))
+			secret := slog.Attr{Key: "email", Value: redact.Value(slog.StringValue("alice@example.com"))}
+
+			logger.Info("top level", secret)
+			logger.Info("in group", slog.Group("user", secret))
+			logger.Info("in log valuer", slog.Any("user", userInfo{email: "alice@example.com"}))
+			logger.With(secret).WithGroup("g").Info("with attrs")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 4 {
+				t.Fatalf("expected 4 lines, got %d: %s", len(lines), buf.String())
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, tt.want) {
+					t.Errorf("expected %q in %s", tt.want, line)
+				}
+				if strings.Contains(line, tt.deny) {
+					t.Errorf("unexpected %q in %s", tt.deny, line)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerHash(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	setPolicy(t, redact.Policy{HashKey: testKey})
+
+	var buf bytes.Buffer
+	logger := slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), redact.ModeHash))
+	logger.Info("hashed", slog.Attr{Key: "email", Value: redact.Value(slog.StringValue("alice@example.com"))})
+	if got := buf.String(); !strings.Contains(got, `"email":"hmac-sha256:`) || strings.Contains(got, "alice") {
+		t.Errorf("expected a hashed email in %s", got)
+	}
+}
+
+func TestHandlerEnabled(t *testing.T) {
+	t.Parallel()
+
+	next := slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn})
+	h := redact.NewHandler(next, redact.ModeReveal)
+	if h.Enabled(t.Context(), slog.LevelInfo) {
+		t.Error("expected info to be disabled")
+	}
+	if !h.Enabled(t.Context(), slog.LevelError) {
+		t.Error("expected error to be enabled")
+	}
+}
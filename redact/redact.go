@@ -0,0 +1,236 @@
+// Package redact keeps sensitive values out of logs. Values wrapped with [Value]
+// render according to the global [Policy]: replaced with [Placeholder], replaced
+// with a short keyed hash, or left as is. The policy can also mark attrs as sensitive
+// by key pattern, and [NewHandler] lets trusted sinks render sensitive values
+// differently from the global policy.
+//
+// The xerrors packages apply this policy automatically: see [xerrors.Log] and
+// errcontext.Sensitive.
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// Placeholder replaces sensitive values under [ModeRedact].
+const Placeholder = "[REDACTED]"
+
+// hashPrefix identifies hashed values under [ModeHash].
+const hashPrefix = "hmac-sha256:"
+
+// Mode controls how sensitive values are rendered.
+type Mode int
+
+const (
+	// ModeRedact replaces sensitive values with [Placeholder]. This is the default.
+	ModeRedact Mode = iota
+	// ModeHash replaces sensitive values with "hmac-sha256:" followed by the first
+	// 16 hex digits of the HMAC-SHA256 of the value keyed with [Policy.HashKey],
+	// so that equal values can still be correlated across log lines without being
+	// revealed. Hashes only hide the values as long as the key is secret: anyone
+	// holding it can hash guesses, such as a list of email addresses, and match
+	// them against the logs.
+	ModeHash
+	// ModeReveal renders sensitive values as is. Intended for trusted sinks.
+	ModeReveal
+)
+
+// Policy is the global redaction policy.
+type Policy struct {
+	// Mode controls how sensitive values are rendered.
+	Mode Mode
+	// Keys lists case-insensitive [path.Match] patterns, such as "*password*" or
+	// "email". Attrs whose key matches any pattern are treated as sensitive even if
+	// they were not explicitly marked with [Value].
+	Keys []string
+	// HashKey is the secret key of the hashes written under [ModeHash], and is
+	// required by it. Use at least 32 random bytes, kept out of the logs and the
+	// source code. Services that share the key produce the same hash for the same
+	// value; changing it makes new hashes unlinkable to old ones.
+	HashKey []byte
+}
+
+var policy atomic.Pointer[Policy]
+
+func init() {
+	policy.Store(&Policy{})
+}
+
+// SetPolicy replaces the global [Policy]. It is safe for concurrent use, but
+// is intended to be called once during program initialization.
+//
+// SetPolicy panics if p.Mode is [ModeHash] and p.HashKey is empty.
+func SetPolicy(p Policy) {
+	if p.Mode == ModeHash && len(p.HashKey) == 0 {
+		panic("redact: SetPolicy called with ModeHash and no HashKey")
+	}
+	keys := make([]string, len(p.Keys))
+	for i, key := range p.Keys {
+		keys[i] = strings.ToLower(key)
+	}
+	p.Keys = keys
+	p.HashKey = bytes.Clone(p.HashKey)
+	policy.Store(&p)
+}
+
+// GetPolicy returns the global [Policy].
+func GetPolicy() Policy {
+	return *policy.Load()
+}
+
+// matches reports whether key matches any of the policy's key patterns.
+func (p *Policy) matches(key string) bool {
+	if len(p.Keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range p.Keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret holds a sensitive value. It implements [slog.LogValuer], [fmt.Stringer]
+// and [json.Marshaler], rendering the value according to the global [Policy]
+// in each case.
+type Secret struct {
+	raw      slog.Value
+	redacted *slog.Value
+	// mapped, if set, is applied to the rendered value; see [Map].
+	mapped *func(slog.Value) slog.Value
+}
+
+// Value marks v as sensitive.
+func Value(v slog.Value) slog.Value {
+	if _, ok := v.Any().(Secret); ok {
+		return v
+	}
+	return slog.AnyValue(Secret{raw: v})
+}
+
+// Alternate marks raw as sensitive, rendering it as redacted instead of
+// [Placeholder] or a hash whenever the policy would hide it. It is used to
+// render [Redactor] payloads.
+func Alternate(raw, redacted slog.Value) slog.Value {
+	return slog.AnyValue(Secret{raw: raw, redacted: &redacted})
+}
+
+// Reveal returns the raw value of v if it was marked sensitive by [Value] or
+// [Alternate], or v unchanged otherwise.
+func Reveal(v slog.Value) slog.Value {
+	if s, ok := v.Any().(Secret); ok {
+		return s.raw
+	}
+	return v
+}
+
+// Map returns v with f applied to the value it renders as, if v was marked
+// sensitive by [Value] or [Alternate]. f is then called whenever v is written,
+// after the redaction mode has chosen what to render, so that it sees the raw
+// value only under [ModeReveal]. If v is not sensitive, Map returns f(v).
+func Map(v slog.Value, f func(slog.Value) slog.Value) slog.Value {
+	s, ok := v.Any().(Secret)
+	if !ok {
+		return f(v)
+	}
+	mapped := f
+	if s.mapped != nil {
+		inner := *s.mapped
+		mapped = func(v slog.Value) slog.Value { return f(inner(v)) }
+	}
+	s.mapped = &mapped
+	return slog.AnyValue(s)
+}
+
+// LogValue implements [slog.LogValuer].
+func (s Secret) LogValue() slog.Value {
+	p := policy.Load()
+	return s.render(p.Mode, p.HashKey)
+}
+
+// String implements [fmt.Stringer].
+func (s Secret) String() string {
+	return s.LogValue().String()
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.LogValue().Resolve().Any())
+}
+
+// render returns the value as it should appear under mode, hashing it with key
+// under [ModeHash], and passes it to the function given to [Map], if any.
+func (s Secret) render(mode Mode, key []byte) slog.Value {
+	v := s.choose(mode, key)
+	if s.mapped != nil {
+		v = (*s.mapped)(v)
+	}
+	return v
+}
+
+// choose returns the value as it should appear under mode, hashing it with key
+// under [ModeHash]. Without a key, nothing is hashed and [Placeholder] is used.
+func (s Secret) choose(mode Mode, key []byte) slog.Value {
+	switch mode {
+	case ModeReveal:
+		return s.raw
+	case ModeHash:
+		if s.redacted != nil {
+			return *s.redacted
+		}
+		if len(key) == 0 {
+			return slog.StringValue(Placeholder)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s.raw.Resolve().String()))
+		return slog.StringValue(hashPrefix + hex.EncodeToString(mac.Sum(nil)[:8]))
+	default:
+		if s.redacted != nil {
+			return *s.redacted
+		}
+		return slog.StringValue(Placeholder)
+	}
+}
+
+// Redactor is implemented by types that know how to produce a redacted copy of
+// themselves. When an error payload implements Redactor, its redacted copy is
+// logged in place of the original unless the policy is [ModeReveal].
+type Redactor interface {
+	Redact() any
+}
+
+// Attrs applies the global policy's key patterns to attrs, returning a copy in
+// which the value of every matching attr, at any depth of nested groups, is
+// marked sensitive as if by [Value]. If the policy has no key patterns, attrs
+// is returned unchanged.
+func Attrs(attrs []slog.Attr) []slog.Attr {
+	p := policy.Load()
+	if len(p.Keys) == 0 {
+		return attrs
+	}
+	return markAttrs(p, attrs)
+}
+
+func markAttrs(p *Policy, attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		switch {
+		case p.matches(attr.Key):
+			attr.Value = Value(attr.Value)
+		case attr.Value.Kind() == slog.KindGroup:
+			attr.Value = slog.GroupValue(markAttrs(p, attr.Value.Group())...)
+		}
+		out[i] = attr
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package redact_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors/redact"
+)
+
+// testKey is the hash key used by tests of ModeHash.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// setPolicy sets the global policy for the duration of the test.
+func setPolicy(t *testing.T, p redact.Policy) {
+	t.Helper()
+	old := redact.GetPolicy()
+	redact.SetPolicy(p)
+	t.Cleanup(func() { redact.SetPolicy(old) })
+}
+
+func TestSecretModes(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	raw := slog.StringValue("alice@example.com")
+	tests := []struct {
+		name string
+		mode redact.Mode
+		want func(string) bool
+	}{
+		{"redact", redact.ModeRedact, func(s string) bool { return s == redact.Placeholder }},
+		{"hash", redact.ModeHash, func(s string) bool {
+			return strings.HasPrefix(s, "hmac-sha256:") && len(s) == len("hmac-sha256:")+16
+		}},
+		{"reveal", redact.ModeReveal, func(s string) bool { return s == "alice@example.com" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPolicy(t, redact.Policy{Mode: tt.mode, HashKey: testKey})
+			v := redact.Value(raw)
+			if v.Kind() != slog.KindLogValuer {
+				t.Fatalf("Value().Kind() = %v, want %v", v.Kind(), slog.KindLogValuer)
+			}
+			if got := v.Resolve().String(); !tt.want(got) {
+				t.Errorf("resolved value = %q", got)
+			}
+			if got := fmt.Sprint(v.Any()); !tt.want(got) {
+				t.Errorf("fmt.Sprint() = %q", got)
+			}
+			data, err := json.Marshal(v.Any())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got string
+			if err := json.Unmarshal(data, &got); err != nil || !tt.want(got) {
+				t.Errorf("json.Marshal() = %s", data)
+			}
+		})
+	}
+}
+
+func TestHashIsStable(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	setPolicy(t, redact.Policy{Mode: redact.ModeHash, HashKey: testKey})
+	a := redact.Value(slog.StringValue("token")).Resolve().String()
+	b := redact.Value(slog.StringValue("token")).Resolve().String()
+	c := redact.Value(slog.StringValue("other")).Resolve().String()
+	if a != b {
+		t.Errorf("expected equal hashes for equal values: %q != %q", a, b)
+	}
+	if a == c {
+		t.Errorf("expected different hashes for different values: %q", a)
+	}
+}
+
+func TestHashIsKeyed(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	mac := hmac.New(sha256.New, testKey)
+	mac.Write([]byte("token"))
+	want := "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)[:8])
+
+	key := slices.Clone(testKey)
+	setPolicy(t, redact.Policy{Mode: redact.ModeHash, HashKey: key})
+	key[0] = 'x' // the policy keeps its own copy
+	a := redact.Value(slog.StringValue("token")).Resolve().String()
+	if a != want {
+		t.Errorf("hash = %q, want %q", a, want)
+	}
+
+	redact.SetPolicy(redact.Policy{Mode: redact.ModeHash, HashKey: []byte("another key")})
+	if b := redact.Value(slog.StringValue("token")).Resolve().String(); a == b {
+		t.Errorf("expected different hashes for different keys: %q", a)
+	}
+}
+
+func TestSetPolicyRequiresHashKey(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	setPolicy(t, redact.GetPolicy())
+	defer func() {
+		if recover() == nil {
+			t.Error("expected SetPolicy to panic without a hash key")
+		}
+	}()
+	redact.SetPolicy(redact.Policy{Mode: redact.ModeHash})
+}
+
+func TestAlternate(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	v := redact.Alternate(slog.StringValue("alice@example.com"), slog.StringValue("a***@example.com"))
+	if got := v.Resolve().String(); got != "a***@example.com" {
+		t.Errorf("redact mode: got %q", got)
+	}
+	setPolicy(t, redact.Policy{Mode: redact.ModeHash, HashKey: testKey})
+	if got := v.Resolve().String(); got != "a***@example.com" {
+		t.Errorf("hash mode: got %q", got)
+	}
+	redact.SetPolicy(redact.Policy{Mode: redact.ModeReveal})
+	if got := v.Resolve().String(); got != "alice@example.com" {
+		t.Errorf("reveal mode: got %q", got)
+	}
+}
+
+func TestAttrs(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	attrs := []slog.Attr{
+		slog.String("Password", "hunter2"),
+		slog.String("user", "alice"),
+		slog.Group("context", slog.String("email", "alice@example.com"), slog.Int("attempt", 3)),
+	}
+
+	// No key patterns: returned unchanged.
+	if got := redact.Attrs(attrs); &got[0] != &attrs[0] {
+		t.Error("expected attrs to be returned unchanged without key patterns")
+	}
+
+	setPolicy(t, redact.Policy{Keys: []string{"*password*", "EMAIL"}})
+	got := redact.Attrs(attrs)
+	if v := got[0].Value.Resolve().String(); v != redact.Placeholder {
+		t.Errorf("Password = %q, want %q", v, redact.Placeholder)
+	}
+	if v := got[1].Value.Resolve().String(); v != "alice" {
+		t.Errorf("user = %q, want alice", v)
+	}
+	inner := got[2].Value.Group()
+	if v := inner[0].Value.Resolve().String(); v != redact.Placeholder {
+		t.Errorf("context.email = %q, want %q", v, redact.Placeholder)
+	}
+	if v := inner[1].Value.Resolve().Int64(); v != 3 {
+		t.Errorf("context.attempt = %d, want 3", v)
+	}
+	// The input is not modified.
+	if v := attrs[0].Value.String(); v != "hunter2" {
+		t.Errorf("input modified: Password = %q", v)
+	}
+}
+
+func TestReveal(t *testing.T) {
+	t.Parallel()
+
+	raw := slog.IntValue(42)
+	if got := redact.Reveal(redact.Value(raw)); got.Int64() != 42 {
+		t.Errorf("Reveal(Value(42)) = %v, want 42", got)
+	}
+	if got := redact.Reveal(raw); got.Int64() != 42 {
+		t.Errorf("Reveal(42) = %v, want 42", got)
+	}
+	// Marking twice does not nest.
+	if got := redact.Reveal(redact.Value(redact.Value(raw))); got.Int64() != 42 {
+		t.Errorf("Reveal(Value(Value(42))) = %v, want 42", got)
+	}
+}
+
+func TestMap(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	upper := func(v slog.Value) slog.Value { return slog.StringValue(strings.ToUpper(v.String())) }
+	suffix := func(v slog.Value) slog.Value { return slog.StringValue(v.String() + "!") }
+
+	if got := redact.Map(slog.StringValue("plain"), upper).String(); got != "PLAIN" {
+		t.Errorf("Map() of a plain value = %q, want %q", got, "PLAIN")
+	}
+
+	v := redact.Map(redact.Map(redact.Value(slog.StringValue("alice")), upper), suffix)
+	tests := []struct {
+		mode redact.Mode
+		want string
+	}{
+		{redact.ModeRedact, "[REDACTED]!"},
+		{redact.ModeReveal, "ALICE!"},
+	}
+	for _, tt := range tests {
+		setPolicy(t, redact.Policy{Mode: tt.mode})
+		if got := v.Resolve().String(); got != tt.want {
+			t.Errorf("mode %d: Map() = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), redact.ModeReveal)).Info("test", "v", v)
+	if got := buf.String(); !strings.Contains(got, `"v":"ALICE!"`) {
+		t.Errorf("handler output %s does not contain the mapped value", got)
+	}
+}
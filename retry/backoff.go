@@ -0,0 +1,46 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay to wait after the given attempt has failed,
+// before the next attempt is made. attempt starts at 1.
+type Backoff func(attempt int) time.Duration
+
+// Constant returns a [Backoff] that always waits for d.
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// Exponential returns a [Backoff] that waits for initial after the first
+// attempt and doubles the delay after every subsequent attempt, never
+// exceeding limit.
+func Exponential(initial, limit time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := initial
+		for i := 1; i < attempt; i++ {
+			if d >= limit/2 {
+				return limit
+			}
+			d *= 2
+		}
+		return min(d, limit)
+	}
+}
+
+// Jitter returns a [Backoff] that waits for a uniformly random duration between
+// zero and the delay returned by b ("full jitter"). This spreads out retries from
+// many callers that failed at the same time.
+func Jitter(b Backoff) Backoff {
+	return func(attempt int) time.Duration {
+		d := b(attempt)
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d + 1)
+	}
+}
@@ -0,0 +1,56 @@
+package retry_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors/retry"
+)
+
+func TestConstant(t *testing.T) {
+	t.Parallel()
+
+	b := retry.Constant(time.Second)
+	for attempt := 1; attempt < 5; attempt++ {
+		if got := b(attempt); got != time.Second {
+			t.Errorf("Constant(1s)(%d) = %v, want 1s", attempt, got)
+		}
+	}
+}
+
+func TestExponential(t *testing.T) {
+	t.Parallel()
+
+	b := retry.Exponential(100*time.Millisecond, time.Second)
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{1000, time.Second},
+	}
+	for _, tt := range tests {
+		if got := b(tt.attempt); got != tt.want {
+			t.Errorf("Exponential(100ms, 1s)(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	t.Parallel()
+
+	b := retry.Jitter(retry.Constant(time.Second))
+	for range 100 {
+		if got := b(1); got < 0 || got > time.Second {
+			t.Errorf("Jitter(Constant(1s))(1) = %v, want within [0, 1s]", got)
+		}
+	}
+
+	if got := retry.Jitter(retry.Constant(0))(1); got != 0 {
+		t.Errorf("Jitter(Constant(0))(1) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,36 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/retry"
+)
+
+func ExampleDo() {
+	calls := 0
+	err := retry.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errclass.WrapAs(errors.New("connection reset"), errclass.Transient)
+		}
+		return nil
+	}, retry.WithBackoff(retry.Constant(time.Millisecond)))
+	fmt.Println(err, calls)
+	// Output:
+	// <nil> 3
+}
+
+func ExampleDo_history() {
+	err := retry.Do(context.Background(), func(context.Context) error {
+		return errclass.WrapAs(errors.New("service unavailable"), errclass.Transient)
+	}, retry.WithMaxAttempts(2), retry.WithBackoff(retry.Constant(time.Millisecond)))
+	history, _ := xerrors.Extract[retry.History](err)
+	fmt.Println(err, len(history))
+	// Output:
+	// service unavailable 2
+}
@@ -0,0 +1,186 @@
+// Package retry calls a function repeatedly for as long as it fails with an
+// error whose [errclass.Class.Severity] is [errclass.Transient]. Any other
+// class of error stops the retries immediately. Every attempt is guarded by
+// [calm.Unpanic], and the returned error carries the [History] of all attempts
+// via [xerrors.Extend].
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/calm"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+)
+
+const (
+	defaultMaxAttempts  = 3
+	defaultInitialDelay = 100 * time.Millisecond
+	defaultMaxDelay     = 5 * time.Second
+)
+
+// Attempt records the outcome of a single call made by [Do].
+type Attempt struct {
+	// Number is the 1-based index of the attempt.
+	Number int
+	// Err is the error returned by the attempt.
+	Err error
+	// Duration is how long the attempt took.
+	Duration time.Duration
+}
+
+// History is the list of failed attempts made by [Do], oldest first.
+type History []Attempt
+
+// LogValue implements [slog.LogValuer].
+// It returns a group containing a single "retry" attr with the number of
+// attempts made and the error message of each, oldest first.
+func (h History) LogValue() slog.Value {
+	if len(h) == 0 {
+		return slog.GroupValue()
+	}
+	errs := make([]any, len(h))
+	for i, attempt := range h {
+		errs[i] = attempt.Err.Error()
+	}
+	return slog.GroupValue(slog.Attr{Key: "retry", Value: slog.GroupValue(
+		slog.Int("attempts", len(h)),
+		slog.Any("errors", errs),
+	)})
+}
+
+type options struct {
+	maxAttempts    int
+	maxElapsed     time.Duration
+	attemptTimeout time.Duration
+	backoff        Backoff
+}
+
+// Option configures the behavior of [Do].
+type Option func(opt *options)
+
+// WithMaxAttempts limits the total number of attempts, including the first.
+// A value of zero or less removes the limit, in which case retries continue until
+// a non-transient error, [WithMaxElapsed], or the context stops them.
+// The default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(opt *options) {
+		opt.maxAttempts = n
+	}
+}
+
+// WithMaxElapsed stops retrying once the next attempt would start more than d
+// after the first attempt started. A value of zero or less removes the limit,
+// which is the default.
+func WithMaxElapsed(d time.Duration) Option {
+	return func(opt *options) {
+		opt.maxElapsed = d
+	}
+}
+
+// WithAttemptTimeout gives every attempt its own context with a timeout of d.
+// An attempt that fails because its own timeout expired is treated as
+// [errclass.Transient] unless it was already classified otherwise.
+// A value of zero or less disables the per-attempt timeout, which is the default.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(opt *options) {
+		opt.attemptTimeout = d
+	}
+}
+
+// WithBackoff sets the strategy used to compute the delay between attempts.
+// The default is [Jitter] applied to [Exponential] starting at 100ms and
+// capped at 5s.
+func WithBackoff(b Backoff) Option {
+	return func(opt *options) {
+		opt.backoff = b
+	}
+}
+
+// Do calls f until it returns nil or an error whose class does not have
+// [errclass.Transient] severity.
+// It also stops once the attempt limit or elapsed time limit is reached, or when
+// ctx is done. Panics inside f are recovered by [calm.Unpanic] and are never retried.
+//
+// On failure, the last error from f is returned extended with the [History] of
+// every attempt, retrievable via [xerrors.Extract]. If ctx is done while waiting
+// between attempts, the returned error also wraps the context's cause.
+// The attrs stored on ctx by [errcontext.WithAttrs] are attached to the returned error.
+func Do(ctx context.Context, f func(ctx context.Context) error, opts ...Option) error {
+	return errcontext.AddFromContext(ctx, do(ctx, f, opts))
+}
+
+// do implements [Do].
+func do(ctx context.Context, f func(ctx context.Context) error, opts []Option) error {
+	// Apply options
+	options := options{
+		maxAttempts: defaultMaxAttempts,
+		backoff:     Jitter(Exponential(defaultInitialDelay, defaultMaxDelay)),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var history History
+	start := time.Now()
+	for number := 1; ; number++ {
+		attemptStart := time.Now()
+		err := attempt(ctx, f, options.attemptTimeout)
+		if err == nil {
+			return nil
+		}
+		history = append(history, Attempt{Number: number, Err: err, Duration: time.Since(attemptStart)})
+
+		if errclass.GetClass(err).Severity() != errclass.Transient {
+			return xerrors.Extend(history, err)
+		}
+		if options.maxAttempts > 0 && number >= options.maxAttempts {
+			return xerrors.Extend(history, err)
+		}
+
+		delay := max(options.backoff(number), 0)
+		if options.maxElapsed > 0 && time.Since(start)+delay > options.maxElapsed {
+			return xerrors.Extend(history, err)
+		}
+		if cause := wait(ctx, delay); cause != nil {
+			return xerrors.Extend(history, fmt.Errorf("%w: %w", cause, err))
+		}
+	}
+}
+
+// attempt makes a single call to f, applying the per-attempt timeout if set.
+func attempt(ctx context.Context, f func(ctx context.Context) error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return calm.Unpanic(func() error {
+			return f(ctx)
+		})
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := calm.Unpanic(func() error {
+		return f(attemptCtx)
+	})
+	// The attempt ran out of time but the caller is still waiting: worth another try.
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		err = errclass.WrapAs(err, errclass.Transient, errclass.WithOnlyUnknown())
+	}
+	return err
+}
+
+// wait blocks for d or until ctx is done, returning the context's cause in the latter case.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
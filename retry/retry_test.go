@@ -0,0 +1,251 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/retry"
+)
+
+var (
+	errTest     = fmt.Errorf("this is a test error")
+	rateLimited = errclass.Register("retry_test_rate_limited", errclass.Transient)
+)
+
+// failing returns a function that fails with err for the first n calls and then succeeds,
+// along with a pointer to the number of calls made.
+func failing(n int, err error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		testName      string
+		failures      int
+		err           error
+		opts          []retry.Option
+		wantErr       bool
+		wantCalls     int
+		expectedClass errclass.Class
+	}{
+		{
+			testName:      "succeeds first time",
+			failures:      0,
+			err:           errTest,
+			wantCalls:     1,
+			expectedClass: errclass.Nil,
+		},
+		{
+			testName:      "retries transient errors until success",
+			failures:      2,
+			err:           errclass.WrapAs(errTest, errclass.Transient),
+			wantCalls:     3,
+			expectedClass: errclass.Nil,
+		},
+		{
+			testName:      "retries registered classes with transient severity",
+			failures:      2,
+			err:           errclass.WrapAs(errTest, rateLimited),
+			wantCalls:     3,
+			expectedClass: errclass.Nil,
+		},
+		{
+			testName:      "gives up after max attempts",
+			failures:      10,
+			err:           errclass.WrapAs(errTest, errclass.Transient),
+			wantErr:       true,
+			wantCalls:     3,
+			expectedClass: errclass.Transient,
+		},
+		{
+			testName:      "unlimited attempts",
+			failures:      10,
+			err:           errclass.WrapAs(errTest, errclass.Transient),
+			opts:          []retry.Option{retry.WithMaxAttempts(0)},
+			wantCalls:     11,
+			expectedClass: errclass.Nil,
+		},
+		{
+			testName:      "does not retry persistent errors",
+			failures:      10,
+			err:           errclass.WrapAs(errTest, errclass.Persistent),
+			wantErr:       true,
+			wantCalls:     1,
+			expectedClass: errclass.Persistent,
+		},
+		{
+			testName:      "does not retry unknown errors",
+			failures:      10,
+			err:           errTest,
+			wantErr:       true,
+			wantCalls:     1,
+			expectedClass: errclass.Unknown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			t.Parallel()
+
+			f, calls := failing(tc.failures, tc.err)
+			opts := append([]retry.Option{retry.WithBackoff(retry.Constant(0))}, tc.opts...)
+			err := retry.Do(context.Background(), f, opts...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *calls != tc.wantCalls {
+				t.Errorf("unexpected number of calls: want %d got %d", tc.wantCalls, *calls)
+			}
+			if class := errclass.GetClass(err); class != tc.expectedClass {
+				t.Errorf("unexpected error class: want: %s got %s", tc.expectedClass, class)
+			}
+			if !tc.wantErr {
+				return
+			}
+			if !errors.Is(err, errTest) {
+				t.Errorf("expected errors.Is to match errTest: got %v", err)
+			}
+			history, ok := xerrors.Extract[retry.History](err)
+			if !ok {
+				t.Fatal("expected history to be attached")
+			}
+			if len(history) != tc.wantCalls {
+				t.Errorf("unexpected history length: want %d got %d", tc.wantCalls, len(history))
+			}
+			for i, attempt := range history {
+				if attempt.Number != i+1 {
+					t.Errorf("unexpected attempt number: want %d got %d", i+1, attempt.Number)
+				}
+				if !errors.Is(attempt.Err, errTest) {
+					t.Errorf("unexpected attempt error: %v", attempt.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := retry.Do(context.Background(), func(context.Context) error {
+		calls++
+		panic("this is a test panic")
+	}, retry.WithBackoff(retry.Constant(0)))
+	if class := errclass.GetClass(err); class != errclass.Panic {
+		t.Errorf("unexpected error class: want: %s got %s", errclass.Panic, class)
+	}
+	if calls != 1 {
+		t.Errorf("expected a panic not to be retried: got %d calls", calls)
+	}
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retry.Do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errclass.WrapAs(errTest, errclass.Transient)
+	}, retry.WithBackoff(retry.Constant(time.Hour)))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled: got %v", err)
+	}
+	if !errors.Is(err, errTest) {
+		t.Errorf("expected errTest: got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("unexpected number of calls: want 1 got %d", calls)
+	}
+	if history, _ := xerrors.Extract[retry.History](err); len(history) != 1 {
+		t.Errorf("unexpected history length: want 1 got %d", len(history))
+	}
+}
+
+func TestDoMaxElapsed(t *testing.T) {
+	t.Parallel()
+
+	f, calls := failing(10, errclass.WrapAs(errTest, errclass.Transient))
+	err := retry.Do(context.Background(), f,
+		retry.WithMaxAttempts(0),
+		retry.WithBackoff(retry.Constant(time.Hour)),
+		retry.WithMaxElapsed(time.Minute),
+	)
+	if !errors.Is(err, errTest) {
+		t.Errorf("expected errTest: got %v", err)
+	}
+	// The first delay already exceeds the limit, so no retry is attempted.
+	if *calls != 1 {
+		t.Errorf("unexpected number of calls: want 1 got %d", *calls)
+	}
+}
+
+func TestDoAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, retry.WithBackoff(retry.Constant(0)), retry.WithAttemptTimeout(time.Millisecond))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a timed out attempt to be retried: got %d calls", calls)
+	}
+}
+
+func TestHistoryLogValue(t *testing.T) {
+	t.Parallel()
+
+	if got := len(retry.History(nil).LogValue().Group()); got != 0 {
+		t.Errorf("expected empty group for empty history: got %d attrs", got)
+	}
+
+	history := retry.History{
+		{Number: 1, Err: errTest},
+		{Number: 2, Err: errTest},
+	}
+	attrs := history.LogValue().Group()
+	if len(attrs) != 1 || attrs[0].Key != "retry" {
+		t.Fatalf("unexpected attrs: %v", attrs)
+	}
+	inner := attrs[0].Value.Group()
+	if len(inner) != 2 || inner[0].Key != "attempts" || inner[0].Value.Int64() != 2 {
+		t.Errorf("unexpected retry group: %v", inner)
+	}
+}
+
+func TestDoContextAttrs(t *testing.T) {
+	t.Parallel()
+
+	ctx := errcontext.WithAttrs(context.Background(), slog.String("request_id", "abc"))
+	err := retry.Do(ctx, func(context.Context) error {
+		return errTest
+	})
+	if got := errcontext.Get(err)["request_id"].String(); got != "abc" {
+		t.Errorf("unexpected request_id: want abc got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package xerrors
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Payload attaches data to a sentinel error created by [NewSentinel]. It is
+// called once with the error to wrap and returns the wrapped error. Any
+// function that wraps an error with [Extend] can be used as a Payload, for
+// example:
+//
+//	xerrors.Payload(func(err error) error { return errclass.WrapKind(err, errclass.KindNotFound) })
+type Payload func(err error) error
+
+// With returns a [Payload] that wraps the sentinel error with data using [Extend].
+func With[T any](data T) Payload {
+	return func(err error) error {
+		return Extend(data, err)
+	}
+}
+
+// sentinel is the error returned by [NewSentinel]. It is only ever used by
+// pointer, so that each sentinel is equal only to itself.
+type sentinel struct {
+	msg     string
+	payload error
+}
+
+// NewSentinel returns a sentinel error with the given message that carries
+// payloads, which are applied once, in order, when the sentinel is created:
+//
+//	var ErrNotFound = xerrors.NewSentinel("not found",
+//		xerrors.With(errclass.Persistent),
+//		xerrors.With(errcode.Code("USER_NOT_FOUND")),
+//	)
+//
+// Like [errors.New], each call returns a distinct error that matches only
+// itself with [errors.Is], however it is wrapped. Unlike a plain sentinel, its
+// payloads are visible to [Extract] and [Log] through any wrapping, so returning
+// the sentinel is enough to classify it. Data attached to a wrapping error
+// takes precedence over the sentinel's own.
+func NewSentinel(msg string, payloads ...Payload) error {
+	s := &sentinel{msg: msg}
+	if len(payloads) > 0 {
+		err := errors.New(msg)
+		for _, p := range payloads {
+			err = p(err)
+		}
+		s.payload = err
+	}
+	return s
+}
+
+// Error returns the sentinel's message.
+func (s *sentinel) Error() string {
+	return s.msg
+}
+
+// Unwrap returns the error carrying the sentinel's payloads, so that [Extract]
+// can find them, or nil if it has none.
+func (s *sentinel) Unwrap() error {
+	return s.payload
+}
+
+// LogValue implements [slog.LogValuer].
+func (s *sentinel) LogValue() slog.Value {
+	return logValue(s)
+}
@@ -0,0 +1,202 @@
+// Package sentryx exports errors to a Sentry-compatible backend. A [Client]
+// converts an error chain into a Sentry event — an exception for each message in
+// the unwrap chain, stack frames from its [stacktrace.StackTrace], tags from its
+// [errclass] classification and [errcode.Code], and extra data from its
+// [errcontext] — and posts it to the project identified by a DSN.
+//
+// The package talks to Sentry's envelope endpoint directly and does not depend
+// on the Sentry SDK.
+package sentryx
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errclass/httpmap"
+)
+
+// clientName identifies this package to Sentry.
+const clientName = "xerrors-sentryx/1"
+
+// Client sends errors to a Sentry project. A Client is safe for concurrent use.
+type Client struct {
+	dsn           string
+	endpoint      string
+	publicKey     string
+	httpClient    *http.Client
+	environment   string
+	release       string
+	serverName    string
+	inAppPrefixes []string
+	now           func() time.Time
+}
+
+// Option configures a [Client] created by [New].
+type Option func(c *Client)
+
+// WithHTTPClient sets the HTTP client used to send events. The default is
+// [http.DefaultClient].
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithEnvironment sets the environment of every event, such as "production".
+func WithEnvironment(environment string) Option {
+	return func(c *Client) {
+		c.environment = environment
+	}
+}
+
+// WithRelease sets the release of every event.
+func WithRelease(release string) Option {
+	return func(c *Client) {
+		c.release = release
+	}
+}
+
+// WithServerName sets the server name of every event.
+func WithServerName(name string) Option {
+	return func(c *Client) {
+		c.serverName = name
+	}
+}
+
+// WithInApp sets the function name prefixes, typically module paths, of stack
+// frames that belong to the application rather than to its dependencies. It
+// defaults to the path of the main module, if known.
+func WithInApp(prefixes ...string) Option {
+	return func(c *Client) {
+		c.inAppPrefixes = prefixes
+	}
+}
+
+// New returns a [Client] for the project identified by dsn, of the form
+// "https://<public_key>@<host>/<project_id>".
+func New(dsn string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sentryx: invalid DSN: %w", err)
+	}
+	// The project ID is the last path segment; anything before it is a path prefix.
+	path, projectID := "", ""
+	if i := strings.LastIndexByte(u.Path, '/'); i >= 0 {
+		path, projectID = u.Path[:i], u.Path[i+1:]
+	}
+	publicKey := u.User.Username()
+	if u.Scheme == "" || u.Host == "" || publicKey == "" || projectID == "" {
+		return nil, fmt.Errorf("sentryx: invalid DSN %q", dsn)
+	}
+
+	c := &Client{
+		dsn:        dsn,
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path, projectID),
+		publicKey:  publicKey,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		c.inAppPrefixes = []string{info.Main.Path}
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Event converts err into a Sentry [Event] with a new event ID, without sending it.
+func (c *Client) Event(err error) Event {
+	class := errclass.GetClass(err)
+	return Event{
+		EventID:     newEventID(),
+		Timestamp:   c.now().UTC(),
+		Platform:    "go",
+		Level:       level(class),
+		Environment: c.environment,
+		Release:     c.release,
+		ServerName:  c.serverName,
+		Exception:   ExceptionList{Values: c.exceptions(err)},
+		Tags:        tags(err),
+		Extra:       extra(err),
+	}
+}
+
+// Capture converts err into an [Event] and sends it, returning the event ID.
+// It does nothing and returns an empty ID if err is nil.
+//
+// Errors from the backend are classified with [httpmap.FromHTTPStatus], so that,
+// for example, a 429 response is [errclass.Transient].
+func (c *Client) Capture(ctx context.Context, err error) (string, error) {
+	if err == nil {
+		return "", nil
+	}
+	event := c.Event(err)
+	body, encErr := c.envelope(event)
+	if encErr != nil {
+		return "", fmt.Errorf("sentryx: encoding event: %w", encErr)
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if reqErr != nil {
+		return "", fmt.Errorf("sentryx: creating request: %w", reqErr)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, c.publicKey))
+
+	resp, doErr := c.httpClient.Do(req)
+	if doErr != nil {
+		return "", errclass.WrapAs(fmt.Errorf("sentryx: sending event: %w", doErr), errclass.Transient, errclass.WithOnlyUnknown())
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return "", httpmap.FromHTTPStatus(fmt.Errorf("sentryx: sending event: %s", resp.Status), resp.StatusCode)
+	}
+	return event.EventID, nil
+}
+
+// envelope encodes event as a Sentry envelope with a single event item.
+func (c *Client) envelope(event Event) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(map[string]any{
+		"event_id": event.EventID,
+		"dsn":      c.dsn,
+		"sent_at":  c.now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	item, err := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// newEventID returns a random 32 character hex event ID.
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
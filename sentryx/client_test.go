@@ -0,0 +1,141 @@
+package sentryx_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/sentryx"
+)
+
+func TestNewInvalidDSN(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range []string{
+		"",
+		"://bad",
+		"https://sentry.example.com/1",
+		"https://key@sentry.example.com/",
+		"https://key@sentry.example.com",
+		"key@sentry.example.com/1",
+	} {
+		if _, err := sentryx.New(dsn); err == nil {
+			t.Errorf("New(%q) expected error", dsn)
+		}
+	}
+}
+
+// request is a request received by the fake Sentry server.
+type request struct {
+	path   string
+	auth   string
+	header map[string]any
+	item   map[string]any
+	event  sentryx.Event
+}
+
+// fakeSentry returns a server that records envelopes and responds with status.
+func fakeSentry(t *testing.T, status int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines := bufio.NewScanner(strings.NewReader(string(body)))
+		var req request
+		req.path, req.auth = r.URL.Path, r.Header.Get("X-Sentry-Auth")
+		for i, target := range []any{&req.header, &req.item, &req.event} {
+			if !lines.Scan() {
+				t.Errorf("envelope has %d lines, want 3", i)
+				break
+			}
+			if err := json.Unmarshal(lines.Bytes(), target); err != nil {
+				t.Errorf("line %d: %v", i, err)
+			}
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := fakeSentry(t, http.StatusOK)
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/prefix/42"
+	c := newClient(t, dsn, sentryx.WithHTTPClient(srv.Client()))
+
+	id, err := c.Capture(t.Context(), loadUser())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(got))
+	}
+	req := got[0]
+	if req.path != "/prefix/api/42/envelope/" {
+		t.Errorf("path = %q", req.path)
+	}
+	if !strings.Contains(req.auth, "sentry_key=public") || !strings.Contains(req.auth, "sentry_version=7") {
+		t.Errorf("X-Sentry-Auth = %q", req.auth)
+	}
+	if req.header["event_id"] != id || req.header["dsn"] != dsn {
+		t.Errorf("envelope header = %v", req.header)
+	}
+	if req.item["type"] != "event" {
+		t.Errorf("item header = %v", req.item)
+	}
+	if req.event.EventID != id || req.event.Exception.Values[0].Value != errTest.Error() {
+		t.Errorf("event = %+v", req.event)
+	}
+
+	// Nil errors are not sent.
+	if id, err := c.Capture(t.Context(), nil); id != "" || err != nil {
+		t.Errorf("Capture(nil) = %q, %v", id, err)
+	}
+	if n := len(requests()); n != 1 {
+		t.Errorf("expected no request for nil error, got %d requests", n)
+	}
+}
+
+func TestCaptureErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status int
+		want   errclass.Class
+	}{
+		{http.StatusTooManyRequests, errclass.Transient},
+		{http.StatusBadRequest, errclass.Persistent},
+		{http.StatusServiceUnavailable, errclass.Transient},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			t.Parallel()
+			srv, _ := fakeSentry(t, tt.status)
+			c := newClient(t, strings.Replace(srv.URL, "http://", "http://public@", 1)+"/1", sentryx.WithHTTPClient(srv.Client()))
+			id, err := c.Capture(t.Context(), errTest)
+			if err == nil || id != "" {
+				t.Fatalf("Capture() = %q, %v, want error", id, err)
+			}
+			if got := errclass.GetClass(err); got != tt.want {
+				t.Errorf("GetClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package sentryx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+// Event is a Sentry event, as described by https://develop.sentry.dev/sdk/data-model/event-payloads/.
+// Only the fields populated by [Client.Event] are included.
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   ExceptionList     `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+// ExceptionList is the exception interface of an [Event].
+type ExceptionList struct {
+	// Values are ordered oldest first, so the last value is the error that was reported.
+	Values []Exception `json:"values"`
+}
+
+// Exception is a single error in the chain of an [Event].
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace is the stack trace of an [Exception].
+type Stacktrace struct {
+	// Frames are ordered oldest first, so the last frame is where the error was captured.
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a single frame of a [Stacktrace].
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// exceptions returns the exception list for err, oldest first. Each error in the
+// unwrap chain becomes an exception, except for wrappers that do not change the
+// message, such as payloads added with [xerrors.Extend]. The [stacktrace.StackTrace]
+// of err, if any, is attached to the last exception.
+func (c *Client) exceptions(err error) []Exception {
+	var values []Exception
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		inner := errors.Unwrap(e)
+		if inner != nil && inner.Error() == e.Error() {
+			continue
+		}
+		values = append(values, Exception{Type: fmt.Sprintf("%T", e), Value: e.Error()})
+	}
+	slices.Reverse(values)
+
+	if st := stacktrace.Extract(err); st != nil && len(values) > 0 {
+		frames := make([]Frame, len(st))
+		for i, f := range st {
+			frames[len(st)-1-i] = Frame{
+				Function: f.Function,
+				Module:   module(f.Function),
+				AbsPath:  f.File,
+				Lineno:   f.LineNumber,
+				InApp:    c.inApp(f.Function),
+			}
+		}
+		values[len(values)-1].Stacktrace = &Stacktrace{Frames: frames}
+	}
+	return values
+}
+
+// inApp reports whether function belongs to one of the in-app prefixes.
+func (c *Client) inApp(function string) bool {
+	for _, prefix := range c.inAppPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// module returns the package path of a fully-qualified function name, such as
+// "example.com/app/db" for "example.com/app/db.(*Store).Get".
+func module(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return ""
+}
+
+// level returns the Sentry level for an error of the given class.
+func level(class errclass.Class) string {
+	switch class.Severity() {
+	case errclass.Panic:
+		return "fatal"
+	case errclass.Transient:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// tags returns the classification of err as Sentry tags.
+func tags(err error) map[string]string {
+	t := map[string]string{"class": errclass.GetClass(err).String()}
+	if kind := errclass.GetKind(err); kind != errclass.KindUnknown {
+		t["kind"] = kind.String()
+	}
+	if code := errcode.Get(err); code != "" {
+		t["code"] = code.String()
+	}
+	return t
+}
+
+// extra returns the [errcontext] of err as Sentry extra data, redacted as it
+// would be for logging.
+func extra(err error) map[string]any {
+	c := errcontext.Get(err)
+	if len(c) == 0 {
+		return nil
+	}
+	return attrsMap(redact.Attrs(c.Flatten()))
+}
+
+// attrsMap converts attrs to a map suitable for JSON encoding, recursing into groups.
+func attrsMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindGroup:
+			m[a.Key] = attrsMap(v.Group())
+		case slog.KindDuration, slog.KindTime:
+			m[a.Key] = v.String()
+		case slog.KindAny:
+			if _, err := json.Marshal(v.Any()); err != nil {
+				m[a.Key] = v.String()
+				continue
+			}
+			m[a.Key] = v.Any()
+		default:
+			m[a.Key] = v.Any()
+		}
+	}
+	return m
+}
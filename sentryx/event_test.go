@@ -0,0 +1,143 @@
+package sentryx_test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcode"
+	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/sentryx"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+var errTest = errors.New("this is a test error")
+
+var testCode = errcode.Register(errcode.Definition{Code: "TEST_SENTRYX", Class: errclass.Persistent})
+
+func newClient(t *testing.T, dsn string, opts ...sentryx.Option) *sentryx.Client {
+	t.Helper()
+	c, err := sentryx.New(dsn, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return c
+}
+
+func loadUser() error {
+	return stacktrace.Wrap(errTest)
+}
+
+func TestEventExceptions(t *testing.T) {
+	t.Parallel()
+
+	err := loadUser()
+	err = errcontext.Add(err, slog.Int("user_id", 42))
+	err = fmt.Errorf("handling request: %w", err)
+	err = xerrors.ExtendMsg(1, err, "serving")
+
+	event := newClient(t, "https://key@sentry.example.com/1", sentryx.WithInApp("github.com/wood-jp/xerrors/sentryx_test")).Event(err)
+	values := event.Exception.Values
+
+	wantValues := []string{
+		"this is a test error",
+		"handling request: this is a test error",
+		"serving: handling request: this is a test error",
+	}
+	if len(values) != len(wantValues) {
+		t.Fatalf("expected %d exceptions, got %d: %+v", len(wantValues), len(values), values)
+	}
+	for i, want := range wantValues {
+		if values[i].Value != want {
+			t.Errorf("values[%d].Value = %q, want %q", i, values[i].Value, want)
+		}
+	}
+	if values[0].Type != "*errors.errorString" || values[1].Type != "*fmt.wrapError" {
+		t.Errorf("unexpected types: %q, %q", values[0].Type, values[1].Type)
+	}
+
+	// The stack trace is attached to the reported (last) exception, oldest frame first.
+	if values[0].Stacktrace != nil || values[1].Stacktrace != nil {
+		t.Error("expected only the last exception to have a stack trace")
+	}
+	frames := values[2].Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if !strings.HasSuffix(last.Function, "sentryx_test.loadUser") || !last.InApp {
+		t.Errorf("last frame = %+v, want in-app loadUser", last)
+	}
+	if last.Module != "github.com/wood-jp/xerrors/sentryx_test" {
+		t.Errorf("Module = %q", last.Module)
+	}
+	if !strings.HasSuffix(frames[len(frames)-2].Function, "sentryx_test.TestEventExceptions") {
+		t.Errorf("expected the caller before loadUser, got %+v", frames[len(frames)-2])
+	}
+}
+
+func TestEventNotInApp(t *testing.T) {
+	t.Parallel()
+
+	event := newClient(t, "https://key@sentry.example.com/1", sentryx.WithInApp("example.com/other")).Event(loadUser())
+	for _, f := range event.Exception.Values[0].Stacktrace.Frames {
+		if f.InApp {
+			t.Errorf("unexpected in-app frame %+v", f)
+		}
+	}
+}
+
+func TestEventTagsAndExtra(t *testing.T) {
+	t.Parallel()
+
+	err := errclass.WrapKind(errcode.New(testCode), errclass.KindNotFound)
+	err = errcontext.Add(err,
+		slog.Int("user_id", 42),
+		slog.Group("req", slog.String("path", "/users")),
+		errcontext.Sensitive(slog.String("email", "alice@example.com")),
+	)
+
+	event := newClient(t, "https://key@sentry.example.com/1", sentryx.WithEnvironment("test"), sentryx.WithRelease("v1")).Event(err)
+	if event.Level != "error" || event.Platform != "go" || event.Environment != "test" || event.Release != "v1" {
+		t.Errorf("unexpected event fields: %+v", event)
+	}
+	if len(event.EventID) != 32 {
+		t.Errorf("EventID = %q, want 32 hex characters", event.EventID)
+	}
+	wantTags := map[string]string{"class": "persistent", "kind": "not_found", "code": "TEST_SENTRYX"}
+	for k, v := range wantTags {
+		if event.Tags[k] != v {
+			t.Errorf("Tags[%q] = %q, want %q", k, event.Tags[k], v)
+		}
+	}
+	if got := event.Extra["user_id"]; got != int64(42) {
+		t.Errorf("Extra[user_id] = %v (%T), want 42", got, got)
+	}
+	if got := event.Extra["req"].(map[string]any)["path"]; got != "/users" {
+		t.Errorf("Extra[req.path] = %v, want /users", got)
+	}
+	if got := event.Extra["email"]; got != "[REDACTED]" {
+		t.Errorf("Extra[email] = %v, want [REDACTED]", got)
+	}
+}
+
+func TestEventLevel(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, "https://key@sentry.example.com/1")
+	tests := []struct {
+		class errclass.Class
+		want  string
+	}{
+		{errclass.Unknown, "error"},
+		{errclass.Transient, "warning"},
+		{errclass.Persistent, "error"},
+		{errclass.Panic, "fatal"},
+	}
+	for _, tt := range tests {
+		if got := c.Event(errclass.WrapAs(errTest, tt.class)).Level; got != tt.want {
+			t.Errorf("level for %v = %q, want %q", tt.class, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package sentryx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/sentryx"
+)
+
+func ExampleClient_Capture() {
+	// A stand-in for a Sentry server.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Println(r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/42"
+
+	client, err := sentryx.New(dsn, sentryx.WithEnvironment("production"))
+	if err != nil {
+		panic(err)
+	}
+	if _, err := client.Capture(context.Background(), errclass.WrapAs(errors.New("disk full"), errclass.Persistent)); err != nil {
+		panic(err)
+	}
+	// Output:
+	// POST /api/42/envelope/
+}
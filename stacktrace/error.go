@@ -1,6 +1,8 @@
 package stacktrace
 
 import (
+	"errors"
+	"fmt"
 	"sync/atomic"
 
 	"github.com/wood-jp/xerrors"
@@ -18,11 +20,67 @@ var Disabled atomic.Bool
 // If err is nil or [Disabled] is true, err is returned unchanged.
 // If err already carries a [StackTrace], it is not wrapped again.
 func Wrap(err error) error {
+	return wrap(err, 1)
+}
+
+// wrap implements [Wrap]. skip is the number of frames between the caller whose
+// stack is captured and wrap.
+func wrap(err error, skip int) error {
 	if Disabled.Load() || err == nil {
 		return err
 	}
 	if _, ok := xerrors.Extract[StackTrace](err); !ok {
-		return xerrors.Extend(GetStack(wrapStackDepth, true), err)
+		return xerrors.Extend(GetStack(wrapStackDepth+skip, true), err)
+	}
+	return err
+}
+
+// New returns an error with the given message and a [StackTrace] captured at the
+// call site. It is shorthand for Wrap(errors.New(msg)).
+func New(msg string) error {
+	return wrap(errors.New(msg), 1)
+}
+
+// Errorf formats an error exactly as [fmt.Errorf] does, including any number of
+// %w verbs, and attaches a [StackTrace] captured at the call site. As with [Wrap],
+// if a wrapped error already carries a [StackTrace] it is kept and no new one is captured.
+func Errorf(format string, args ...any) error {
+	return wrap(fmt.Errorf(format, args...), 1)
+}
+
+// Builder creates errors that carry a [StackTrace] together with a fixed set of
+// payloads, such as a class and logging context, so that the whole error is built
+// in a single call. Create one with [With]; the zero Builder adds no payloads.
+type Builder struct {
+	payloads []xerrors.Payload
+}
+
+// With returns a [Builder] that applies payloads, in order, to every error it creates:
+//
+//	err := stacktrace.With(
+//		xerrors.With(errclass.Persistent),
+//		errcontext.With(slog.Int("user_id", id)),
+//	).Errorf("loading user: %w", err)
+//
+// A Builder may be stored and reused, for example as a package-level variable.
+func With(payloads ...xerrors.Payload) Builder {
+	return Builder{payloads: payloads}
+}
+
+// New is like the package-level [New], and applies the builder's payloads.
+func (b Builder) New(msg string) error {
+	return b.apply(wrap(errors.New(msg), 1))
+}
+
+// Errorf is like the package-level [Errorf], and applies the builder's payloads.
+func (b Builder) Errorf(format string, args ...any) error {
+	return b.apply(wrap(fmt.Errorf(format, args...), 1))
+}
+
+// apply wraps err with each of the builder's payloads in turn.
+func (b Builder) apply(err error) error {
+	for _, p := range b.payloads {
+		err = p(err)
 	}
 	return err
 }
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strings"
 
 	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/errclass"
+	"github.com/wood-jp/xerrors/errcontext"
 	"github.com/wood-jp/xerrors/stacktrace"
 )
 
@@ -48,3 +51,39 @@ func ExampleExtract_noStack() {
 	// Output:
 	// true
 }
+
+func ExampleErrorf() {
+	var buf bytes.Buffer
+	err := stacktrace.Errorf("loading config: %w", errors.New("file not found"))
+	newLogger(&buf).Error("startup failed", xerrors.Log(err))
+	fmt.Print(normalizeStack(buf.String()))
+	// Output:
+	// {"level":"ERROR","msg":"startup failed","error":{"error":"loading config: file not found","error_detail":{"stacktrace":[{"func":"github.com/wood-jp/xerrors/stacktrace_test.ExampleErrorf","line":0,"source":"..."},{"func":"main.main","line":0,"source":"..."}]}}}
+}
+
+func ExampleWith() {
+	var buf bytes.Buffer
+	err := stacktrace.With(
+		xerrors.With(errclass.Persistent),
+		errcontext.With(slog.Int("user_id", 42)),
+	).New("user not found")
+	newLogger(&buf).Error("lookup failed", xerrors.Log(err))
+	fmt.Print(normalizeStack(buf.String()))
+	// Output:
+	// {"level":"ERROR","msg":"lookup failed","error":{"error":"user not found","error_detail":{"stacktrace":[{"func":"github.com/wood-jp/xerrors/stacktrace_test.ExampleWith","line":0,"source":"..."},{"func":"main.main","line":0,"source":"..."}],"class":"persistent","context":{"user_id":42}}}}
+}
+
+func ExampleSetSourceOptions() {
+	stacktrace.SetSourceOptions(stacktrace.SourceOptions{Lines: 1})
+	defer stacktrace.SetSourceOptions(stacktrace.SourceOptions{})
+
+	err := stacktrace.New("invalid state")
+	frame := stacktrace.Extract(err)[0]
+	for _, line := range frame.Context.Lines {
+		fmt.Printf("%q\n", strings.TrimSpace(line))
+	}
+	// Output:
+	// ""
+	// "err := stacktrace.New(\"invalid state\")"
+	// "frame := stacktrace.Extract(err)[0]"
+}
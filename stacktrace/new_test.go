@@ -0,0 +1,76 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+func TestNewAndErrorf(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("a")
+	errB := errors.New("b")
+	builder := stacktrace.With(xerrors.With(42))
+
+	tests := []struct {
+		name    string
+		err     error
+		wantMsg string
+	}{
+		{"New", stacktrace.New("failed"), "failed"},
+		{"Errorf", stacktrace.Errorf("failed %d", 1), "failed 1"},
+		{"Errorf multiple %w", stacktrace.Errorf("%w and %w", errA, errB), "a and b"},
+		{"Builder New", builder.New("failed"), "failed"},
+		{"Builder Errorf", builder.Errorf("failed: %w", errA), "failed: a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.err.Error(); got != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMsg)
+			}
+			trace := stacktrace.Extract(tt.err)
+			if len(trace) == 0 {
+				t.Fatal("expected stack trace")
+			}
+			if got := trace[0].Function; !strings.HasSuffix(got, "stacktrace_test.TestNewAndErrorf") {
+				t.Errorf("first frame = %q, want the caller", got)
+			}
+		})
+	}
+
+	err := stacktrace.Errorf("%w and %w", errA, errB)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Error("expected errors.Is to match every wrapped error")
+	}
+	if got, ok := xerrors.Extract[int](builder.New("failed")); !ok || got != 42 {
+		t.Errorf("Extract() = %d, %v, want 42, true", got, ok)
+	}
+}
+
+func TestErrorfKeepsExistingStack(t *testing.T) {
+	t.Parallel()
+
+	inner := a()
+	err := stacktrace.Errorf("outer: %w", inner)
+	if got, want := len(stacktrace.Extract(err)), len(stacktrace.Extract(inner)); got != want {
+		t.Errorf("expected the inner stack trace to be kept: got %d frames, want %d", got, want)
+	}
+}
+
+func TestNewDisabled(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	stacktrace.Disabled.Store(true)
+	t.Cleanup(func() { stacktrace.Disabled.Store(false) })
+
+	err := stacktrace.With(xerrors.With(42)).New("failed")
+	if trace := stacktrace.Extract(err); trace != nil {
+		t.Errorf("expected nil stacktrace: got %v", trace)
+	}
+	if _, ok := xerrors.Extract[int](err); !ok {
+		t.Error("expected payloads to be applied while disabled")
+	}
+}
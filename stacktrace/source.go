@@ -0,0 +1,199 @@
+package stacktrace
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxFileSize is the default for [SourceOptions.MaxFileSize].
+	defaultMaxFileSize = 1 << 20
+	// defaultMaxCacheSize is the default for [SourceOptions.MaxCacheSize].
+	defaultMaxCacheSize = 16 << 20
+	// maxLineLen is the maximum length in bytes of a line of source context.
+	// Longer lines are cut and suffixed with "...".
+	maxLineLen = 200
+)
+
+// SourceContext holds the lines of source code around the line of a [Frame].
+type SourceContext struct {
+	// FirstLine is the line number of the first line in Lines.
+	FirstLine int `json:"first_line"`
+	// Lines are the lines of source, without line endings.
+	Lines []string `json:"lines"`
+}
+
+// SourceOptions configures source context: lines of source code attached to each
+// [Frame] captured by [GetStack], and so by [Wrap], [New] and [Errorf]. It is
+// meant for development and CI, where the source files are present on the
+// machine running the program, for example to make test failure reports
+// self-explanatory. Frames whose file cannot be read are left without context.
+//
+// Source files are read once and cached in memory.
+type SourceOptions struct {
+	// Lines is the number of lines to include before and after the line of each
+	// frame. Zero, the default, disables source context.
+	Lines int
+	// MaxFileSize is the size in bytes above which a source file is not read.
+	// Zero means 1 MiB.
+	MaxFileSize int64
+	// MaxCacheSize is the total size in bytes of the source files kept in memory.
+	// When a new file would exceed it, the least recently used files are evicted
+	// to make room. Files larger than it are read every time. Zero means 16 MiB.
+	MaxCacheSize int64
+}
+
+var sourceOptions atomic.Pointer[SourceOptions]
+
+func init() {
+	sourceOptions.Store(&SourceOptions{})
+}
+
+// SetSourceOptions replaces the global [SourceOptions] and clears the source
+// cache. It is safe for concurrent use, but is intended to be called once during
+// program initialization, for example from TestMain:
+//
+//	stacktrace.SetSourceOptions(stacktrace.SourceOptions{Lines: 2})
+func SetSourceOptions(o SourceOptions) {
+	if o.MaxFileSize <= 0 {
+		o.MaxFileSize = defaultMaxFileSize
+	}
+	if o.MaxCacheSize <= 0 {
+		o.MaxCacheSize = defaultMaxCacheSize
+	}
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	sources.files, sources.size = nil, 0
+	sourceOptions.Store(&o)
+}
+
+// GetSourceOptions returns the global [SourceOptions].
+func GetSourceOptions() SourceOptions {
+	return *sourceOptions.Load()
+}
+
+// sources caches the lines of the source files read for source context.
+var sources struct {
+	mu    sync.Mutex
+	files map[string]*cachedSource
+	size  int64
+	// clock is incremented on every use of the cache, to order the files by
+	// recency of use.
+	clock uint64
+}
+
+// cachedSource is an entry of the source cache. Nil lines record a file that
+// could not be read.
+type cachedSource struct {
+	lines []string
+	size  int64
+	used  uint64
+}
+
+// sourceContext returns the source context of line in file, or nil if source
+// context is disabled or the file cannot be read.
+func sourceContext(file string, line int) *SourceContext {
+	o := sourceOptions.Load()
+	if o.Lines <= 0 || line <= 0 {
+		return nil
+	}
+	lines := sourceLines(file, o)
+	if line > len(lines) {
+		return nil
+	}
+	first := max(line-o.Lines, 1)
+	last := min(line+o.Lines, len(lines))
+	return &SourceContext{FirstLine: first, Lines: slices.Clone(lines[first-1 : last])}
+}
+
+// sourceLines returns the lines of file, reading it into the cache if needed.
+// The file is read without holding the lock, so a slow file system does not
+// block other captures.
+func sourceLines(file string, o *SourceOptions) []string {
+	if lines, ok := cachedLines(file); ok {
+		return lines
+	}
+	lines, size := readSource(file, o.MaxFileSize)
+
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	if c, ok := sources.files[file]; ok {
+		// Another goroutine read the file first.
+		sources.clock++
+		c.used = sources.clock
+		return c.lines
+	}
+	// Leave out files that can never fit, and files read with options that were
+	// replaced, and the cache cleared, while reading.
+	if size > o.MaxCacheSize || sourceOptions.Load() != o {
+		return lines
+	}
+	for sources.size+size > o.MaxCacheSize {
+		evictSource()
+	}
+	if sources.files == nil {
+		sources.files = map[string]*cachedSource{}
+	}
+	sources.clock++
+	sources.files[file] = &cachedSource{lines: lines, size: size, used: sources.clock}
+	sources.size += size
+	return lines
+}
+
+// cachedLines returns the cached lines of file, if any, and marks it as used.
+func cachedLines(file string) ([]string, bool) {
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	c, ok := sources.files[file]
+	if !ok {
+		return nil, false
+	}
+	sources.clock++
+	c.used = sources.clock
+	return c.lines, true
+}
+
+// evictSource removes the least recently used file from the cache. It must be
+// called with sources.mu held and a non-empty cache.
+func evictSource() {
+	var oldest string
+	var used uint64
+	for file, c := range sources.files {
+		if used == 0 || c.used < used {
+			oldest, used = file, c.used
+		}
+	}
+	sources.size -= sources.files[oldest].size
+	delete(sources.files, oldest)
+}
+
+// readSource reads the lines of file, returning nil if it cannot be read or is
+// larger than maxSize, together with the number of bytes read.
+func readSource(file string, maxSize int64) ([]string, int64) {
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxSize {
+		return nil, 0
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, 0
+	}
+	lines := strings.Split(string(bytes.TrimSuffix(data, []byte("\n"))), "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if len(line) > maxLineLen {
+			n := maxLineLen
+			for n > 0 && !utf8.RuneStart(line[n]) {
+				n--
+			}
+			line = line[:n] + "..."
+		}
+		lines[i] = line
+	}
+	return lines, int64(len(data))
+}
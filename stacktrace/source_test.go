@@ -0,0 +1,234 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wood-jp/xerrors"
+	"github.com/wood-jp/xerrors/stacktrace"
+)
+
+// setSourceOptions enables source context for the duration of the test.
+func setSourceOptions(t *testing.T, o stacktrace.SourceOptions) {
+	t.Helper()
+	stacktrace.SetSourceOptions(o)
+	t.Cleanup(func() {
+		stacktrace.SetSourceOptions(stacktrace.SourceOptions{})
+	})
+}
+
+func newWithSource() error {
+	// before
+	return stacktrace.New("with source")
+	// after
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContext(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1})
+
+	frame := stacktrace.Extract(newWithSource())[0]
+	want := &stacktrace.SourceContext{
+		FirstLine: frame.LineNumber - 1,
+		Lines: []string{
+			"\t// before",
+			`	return stacktrace.New("with source")`,
+			"\t// after",
+		},
+	}
+	if !reflect.DeepEqual(frame.Context, want) {
+		t.Fatalf("Context = %+v, want %+v", frame.Context, want)
+	}
+
+	got := fmt.Sprintf("%+v", frame)
+	wantLine := fmt.Sprintf("\t> %d | \treturn stacktrace.New(\"with source\")", frame.LineNumber)
+	if !strings.Contains(got, wantLine) {
+		t.Errorf("%%+v = %q, want it to contain %q", got, wantLine)
+	}
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContextDisabled(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{})
+
+	for _, frame := range stacktrace.Extract(newWithSource()) {
+		if frame.Context != nil {
+			t.Fatalf("Context = %+v, want nil", frame.Context)
+		}
+	}
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContextMaxFileSize(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1, MaxFileSize: 10})
+
+	if frame := stacktrace.Extract(newWithSource())[0]; frame.Context != nil {
+		t.Fatalf("Context = %+v, want nil", frame.Context)
+	}
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContextMaxCacheSize(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1, MaxCacheSize: 10})
+
+	// Files too large to cache are still read.
+	for range 2 {
+		if frame := stacktrace.Extract(newWithSource())[0]; frame.Context == nil {
+			t.Fatal("Context = nil, want source context")
+		}
+	}
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContextEviction(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1})
+
+	// The stack trace spans bench_test.go and this file. Make the cache one byte
+	// too small for both, so that concurrent captures keep evicting and
+	// re-reading them.
+	var total int64
+	want := map[string]bool{}
+	for _, frame := range stacktrace.Extract(wrapDepth1(errors.New("test error"))) {
+		if frame.Context != nil && !want[frame.File] {
+			info, err := os.Stat(frame.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += info.Size()
+			want[frame.File] = true
+		}
+	}
+	if len(want) < 2 {
+		t.Skipf("stack trace has source for %d files, want at least 2", len(want))
+	}
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1, MaxCacheSize: total - 1})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				for _, frame := range stacktrace.Extract(wrapDepth1(errors.New("test error"))) {
+					if want[frame.File] && frame.Context == nil {
+						t.Errorf("%s: Context = nil, want source context", frame.File)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+//nolint:paralleltest // test uses package-level variable
+func TestSourceContextLog(t *testing.T) {
+	setSourceOptions(t, stacktrace.SourceOptions{Lines: 1})
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(newWithSource()))
+
+	var record struct {
+		Error struct {
+			Detail struct {
+				Stacktrace []stacktrace.Frame `json:"stacktrace"`
+			} `json:"error_detail"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	frame := record.Error.Detail.Stacktrace[0]
+	if frame.Context == nil || frame.Context.FirstLine != frame.LineNumber-1 || len(frame.Context.Lines) != 3 {
+		t.Fatalf("Context = %+v, want 3 lines from %d", frame.Context, frame.LineNumber-1)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	st := stacktrace.StackTrace{
+		{
+			File:       "/app/main.go",
+			LineNumber: 10,
+			Function:   "main.b",
+			Context: &stacktrace.SourceContext{
+				FirstLine: 9,
+				Lines:     []string{"func b() error {", "\treturn errors.New(\"b\")", "}"},
+			},
+		},
+		{File: "/app/main.go", LineNumber: 20, Function: "main.main"},
+	}
+
+	tests := []struct {
+		format string
+		value  any
+		want   string
+	}{
+		{"%v", st[1], "{/app/main.go 20 main.main}"},
+		{"%v", st[0], "{/app/main.go 10 main.b}"},
+		{"%s", st[1], "{/app/main.go %!s(int=20) main.main}"},
+		{"%d", st[1], "{%!d(string=/app/main.go) 20 %!d(string=main.main)}"},
+		{"%+v", st[1], "{File:/app/main.go LineNumber:20 Function:main.main}"},
+		{
+			"%#v", st[1],
+			`stacktrace.Frame{File:"/app/main.go", LineNumber:20, Function:"main.main", Context:(*stacktrace.SourceContext)(nil)}`,
+		},
+		{"%v", st, "[{/app/main.go 10 main.b} {/app/main.go 20 main.main}]"},
+		{"%v", stacktrace.StackTrace(nil), "[]"},
+		{"%+v", st[1:], "[{File:/app/main.go LineNumber:20 Function:main.main}]"},
+		{"%#v", stacktrace.StackTrace(nil), "stacktrace.StackTrace(nil)"},
+		{
+			"%+v", st,
+			"main.b\n\t/app/main.go:10\n" +
+				"\t   9 | func b() error {\n" +
+				"\t> 10 | \treturn errors.New(\"b\")\n" +
+				"\t  11 | }\n" +
+				"main.main\n\t/app/main.go:20\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			if got := fmt.Sprintf(tt.format, tt.value); got != tt.want {
+				t.Errorf("Sprintf(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWithoutSourceContext(t *testing.T) {
+	t.Parallel()
+
+	// Without source context, every verb prints what it did before frames
+	// implemented fmt.Formatter.
+	type oldFrame struct {
+		File       string
+		LineNumber int
+		Function   string
+	}
+	st := stacktrace.Extract(stacktrace.Wrap(errors.New("test error")))
+	old := make([]oldFrame, len(st))
+	for i, frame := range st {
+		old[i] = oldFrame{frame.File, frame.LineNumber, frame.Function}
+	}
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		if got, want := fmt.Sprintf(format, st), fmt.Sprintf(format, old); got != want {
+			t.Errorf("Sprintf(%q) = %q, want %q", format, got, want)
+		}
+		if got, want := fmt.Sprintf(format, st[0]), fmt.Sprintf(format, old[0]); got != want {
+			t.Errorf("Sprintf(%q) = %q, want %q", format, got, want)
+		}
+	}
+	if want := "[{File:"; !strings.HasPrefix(fmt.Sprintf("%+v", st), want) {
+		t.Errorf("%%+v = %q, want prefix %q", fmt.Sprintf("%+v", st), want)
+	}
+}
@@ -1,12 +1,15 @@
 // Package stacktrace captures and formats call stack information using the Go runtime.
 // It provides [GetStack] to capture the current program stack, and [Wrap] / [Extract]
 // to attach a [StackTrace] to an error. [StackTrace] implements [slog.LogValuer] for
-// structured logging integration.
+// structured logging integration, and [fmt.Formatter] for printing with %+v.
+// Frames can optionally carry the surrounding source code; see [SetSourceOptions].
 package stacktrace
 
 import (
+	"fmt"
 	"log/slog"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -24,14 +27,108 @@ type Frame struct {
 	LineNumber int `json:"line"`
 	// Function is the fully-qualified function name of the frame.
 	Function string `json:"func"`
+	// Context holds the source code around the line of the frame, or nil if
+	// source context is disabled or the file could not be read. See [SetSourceOptions].
+	Context *SourceContext `json:"context,omitempty"`
+}
+
+// Format implements [fmt.Formatter]. If the frame has source context, the %+v verb
+// prints the function name and, on the next line, a tab and "file:line", followed
+// by the source context, with the frame's line marked by ">". Otherwise, and for
+// every other verb, the File, LineNumber and Function fields are formatted as the
+// default struct formatting does, leaving out the source context, and %#v prints
+// the frame as a Go value.
+func (f Frame) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+') && f.Context != nil:
+		f.formatLong(s)
+	case verb == 'v' && s.Flag('#'):
+		type Frame plainFrame
+		fmt.Fprintf(s, "%#v", Frame(f))
+	default:
+		fmt.Fprintf(s, fmt.FormatString(s, verb), struct {
+			File       string
+			LineNumber int
+			Function   string
+		}{f.File, f.LineNumber, f.Function})
+	}
+}
+
+// plainFrame has the fields of [Frame] without its methods, so that it can be
+// printed with the default formatting.
+type plainFrame Frame
+
+// formatLong writes the %+v form of f, without a trailing newline.
+func (f Frame) formatLong(s fmt.State) {
+	fmt.Fprintf(s, "%s\n\t%s:%d", f.Function, f.File, f.LineNumber)
+	if f.Context == nil {
+		return
+	}
+	width := len(strconv.Itoa(f.Context.FirstLine + len(f.Context.Lines) - 1))
+	for i, line := range f.Context.Lines {
+		number := f.Context.FirstLine + i
+		marker := " "
+		if number == f.LineNumber {
+			marker = ">"
+		}
+		fmt.Fprintf(s, "\n\t%s %*d | %s", marker, width, number, line)
+	}
 }
 
 // StackTrace represents a program stack trace as a series of frames.
 type StackTrace []Frame
 
+// Format implements [fmt.Formatter]. If any frame has source context, the %+v verb
+// prints each frame on its own lines, as [Frame.Format] does for a frame with
+// source context. Otherwise, and for every other verb, the frames are formatted as
+// a slice, with each frame formatted as by [Frame.Format].
+func (st StackTrace) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+') && st.hasContext():
+		for _, frame := range st {
+			frame.formatLong(s)
+			fmt.Fprint(s, "\n")
+		}
+	case verb == 'v' && s.Flag('#'):
+		if st == nil {
+			fmt.Fprint(s, "stacktrace.StackTrace(nil)")
+			return
+		}
+		fmt.Fprint(s, "stacktrace.StackTrace{")
+		for i, frame := range st {
+			if i > 0 {
+				fmt.Fprint(s, ", ")
+			}
+			fmt.Fprintf(s, "%#v", frame)
+		}
+		fmt.Fprint(s, "}")
+	default:
+		format := fmt.FormatString(s, verb)
+		fmt.Fprint(s, "[")
+		for i, frame := range st {
+			if i > 0 {
+				fmt.Fprint(s, " ")
+			}
+			fmt.Fprintf(s, format, frame)
+		}
+		fmt.Fprint(s, "]")
+	}
+}
+
+// hasContext reports whether any frame of st has source context.
+func (st StackTrace) hasContext() bool {
+	for _, frame := range st {
+		if frame.Context != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // LogValue implements [slog.LogValuer].
 // It returns a group containing a single "stacktrace" attr whose value is an
-// array of frame objects, each with "func", "line", and "source" keys.
+// array of frame objects, each with "func", "line", and "source" keys, and a
+// "context" object with "first_line" and "lines" keys if the frame has source context.
 //
 // Each frame is represented as map[string]any rather than [slog.GroupValue] because
 // slog handlers only resolve [slog.LogValuer] at the top level of an attribute value —
@@ -42,19 +139,38 @@ type StackTrace []Frame
 func (st StackTrace) LogValue() slog.Value {
 	frames := make([]any, len(st))
 	for i, frame := range st {
-		frames[i] = map[string]any{
+		m := map[string]any{
 			"func":   frame.Function,
 			"line":   frame.LineNumber,
 			"source": frame.File,
 		}
+		if frame.Context != nil {
+			m["context"] = map[string]any{
+				"first_line": frame.Context.FirstLine,
+				"lines":      frame.Context.Lines,
+			}
+		}
+		frames[i] = m
 	}
 	return slog.GroupValue(slog.Any("stacktrace", slog.AnyValue(frames)))
 }
 
+// FingerprintComponent implements [xerrors.Fingerprinter], contributing the function
+// names of the frames, innermost first. Line numbers are left out so that the
+// fingerprint survives unrelated edits to the same file.
+func (st StackTrace) FingerprintComponent() (string, []string) {
+	functions := make([]string, len(st))
+	for i, frame := range st {
+		functions[i] = frame.Function
+	}
+	return "frames", functions
+}
+
 // GetStack captures the current program stack trace and returns it as a [StackTrace].
 // skipFrames controls how many frames to skip: passing 1 makes GetStack itself the first captured frame.
 // When skipRuntime is true, frames from the Go runtime (e.g. runtime.main, runtime.panic)
 // and the testing package are omitted from the result.
+// Frames carry source context if it is enabled with [SetSourceOptions].
 func GetStack(skipFrames int, skipRuntime bool) StackTrace {
 	pc := make([]uintptr, maxFrames)
 	n := runtime.Callers(skipFrames, pc)
@@ -76,8 +192,26 @@ func GetStack(skipFrames int, skipRuntime bool) StackTrace {
 			File:       frame.File,
 			LineNumber: frame.Line,
 			Function:   frame.Function,
+			Context:    sourceContext(frame.File, frame.Line),
 		})
 	}
 
 	return stackTrace
 }
+
+// Caller returns the single [Frame] skipFrames levels up the call stack, using the
+// same convention as [GetStack]: passing 1 returns the frame of Caller itself.
+// It is much cheaper than [GetStack] when only one frame is needed, and never
+// carries source context. If there is no such frame, the zero Frame is returned.
+func Caller(skipFrames int) Frame {
+	pc := make([]uintptr, 1)
+	if runtime.Callers(skipFrames, pc) == 0 {
+		return Frame{}
+	}
+	frame, _ := runtime.CallersFrames(pc).Next()
+	return Frame{
+		File:       frame.File,
+		LineNumber: frame.Line,
+		Function:   frame.Function,
+	}
+}
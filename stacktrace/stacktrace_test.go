@@ -146,3 +146,21 @@ func TestStackTraceTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestCaller(t *testing.T) {
+	t.Parallel()
+
+	if got := stacktrace.Caller(1).Function; !strings.HasSuffix(got, "stacktrace.Caller") {
+		t.Errorf("Caller(1).Function = %q, want stacktrace.Caller", got)
+	}
+	frame := stacktrace.Caller(2)
+	if !strings.HasSuffix(frame.Function, "stacktrace_test.TestCaller") {
+		t.Errorf("Caller(2).Function = %q, want stacktrace_test.TestCaller", frame.Function)
+	}
+	if !strings.HasSuffix(frame.File, "stacktrace_test.go") || frame.LineNumber == 0 {
+		t.Errorf("Caller(2) = %+v, want a line in stacktrace_test.go", frame)
+	}
+	if got := stacktrace.Caller(1000); got != (stacktrace.Frame{}) {
+		t.Errorf("Caller(1000) = %+v, want zero Frame", got)
+	}
+}
@@ -5,25 +5,46 @@ package xerrors
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+
+	"github.com/wood-jp/xerrors/redact"
 )
 
-// extendedErrFlat is the unexported interface used by [collectDetails] to walk
-// the error chain and gather flat log attributes from each extended-error layer.
+// extendedErrFlat is the unexported interface used by [collectDetails] and
+// [Fingerprint] to walk the error chain and gather flat log attributes and
+// fingerprint components from each extended-error layer.
 type extendedErrFlat interface {
 	flatLogAttrs() []slog.Attr
 	innerError() error
+	fingerprintComponent() (name string, values []string, ok bool)
+	payload() any
+}
+
+// shadower is implemented by payloads whose log attrs supersede those of some
+// inner payloads. errcontext uses it so that the outermost layer of context,
+// which logs the context merged from the layers below it, replaces them in log
+// output rather than repeating them.
+type shadower interface {
+	// Shadows reports whether the inner payload data is superseded.
+	Shadows(data any) bool
 }
 
 // ExtendedError wraps an error with an additional value of type T.
 // It implements [error], [interface{ Unwrap() error }], and [slog.LogValuer].
 type ExtendedError[T any] struct {
 	err  error
+	msg  string
 	Data T
 }
 
-// Error returns the error string of the underlying error.
+// Error returns the error string of the underlying error, prefixed with the
+// message given to [ExtendMsg] if any.
 func (e ExtendedError[T]) Error() string {
+	if e.msg != "" {
+		return e.msg + ": " + e.err.Error()
+	}
 	return e.err.Error()
 }
 
@@ -43,23 +64,70 @@ func (e ExtendedError[T]) innerError() error {
 	return e.err
 }
 
+// fingerprintComponent implements [extendedErrFlat], returning the [Fingerprint]
+// component of the data if T implements [Fingerprinter].
+func (e ExtendedError[T]) fingerprintComponent() (string, []string, bool) {
+	f, ok := any(e.Data).(Fingerprinter)
+	if !ok {
+		return "", nil, false
+	}
+	name, values := f.FingerprintComponent()
+	return name, values, true
+}
+
+// payload implements [extendedErrFlat], returning the data.
+func (e ExtendedError[T]) payload() any {
+	return e.Data
+}
+
 // flatLogAttrs implements [extendedErrFlat]. If T implements [slog.LogValuer]
 // and its resolved value is a group, the group attrs are returned directly.
 // Otherwise a single "data" attr wrapping the value is returned.
+// If T implements [redact.Redactor], each attr is marked sensitive and rendered
+// from the redacted copy of the data unless the redaction policy reveals it.
 func (e ExtendedError[T]) flatLogAttrs() []slog.Attr {
-	val := slog.AnyValue(e.Data)
+	attrs := dataLogAttrs(e.Data)
+	if r, ok := any(e.Data).(redact.Redactor); ok {
+		return redactedLogAttrs(attrs, dataLogAttrs(r.Redact()))
+	}
+	return attrs
+}
+
+// dataLogAttrs returns the flat log attributes of a single payload.
+func dataLogAttrs(data any) []slog.Attr {
+	val := slog.AnyValue(data)
 	for val.Kind() == slog.KindLogValuer {
 		val = val.LogValuer().LogValue()
 	}
 	if val.Kind() == slog.KindGroup {
 		return val.Group()
 	}
-	return []slog.Attr{slog.Any("data", e.Data)}
+	return []slog.Attr{slog.Any("data", data)}
+}
+
+// redactedLogAttrs marks every attr in raw as sensitive, using the attr with
+// the same key in redacted (if any) as its redacted form.
+func redactedLogAttrs(raw, redacted []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(raw))
+	for i, attr := range raw {
+		value := redact.Value(attr.Value)
+		for _, r := range redacted {
+			if r.Key == attr.Key {
+				value = redact.Alternate(attr.Value, r.Value)
+				break
+			}
+		}
+		out[i] = slog.Attr{Key: attr.Key, Value: value}
+	}
+	return out
 }
 
 func logValue(err error) slog.Value {
-	detailAttrs := collectDetails(err)
+	detailAttrs := redact.Attrs(collectDetails(err))
 	result := []slog.Attr{slog.String("error", err.Error())}
+	if opts := logFingerprint.Load(); opts != nil {
+		result = append(result, slog.String("fingerprint", Fingerprint(err, *opts...)))
+	}
 	if len(detailAttrs) > 0 {
 		result = append(result, slog.Attr{
 			Key:   "error_detail",
@@ -71,19 +139,43 @@ func logValue(err error) slog.Value {
 
 // collectDetails walks the error chain and gathers flat log attributes from
 // every [extendedErrFlat] layer, in innermost-to-outermost order.
+// The outermost [shadower] payload is logged in the position of the innermost
+// payload it shadows, and the payloads it shadows are skipped without being
+// rendered. Payloads outside it are never shadowed.
 func collectDetails(err error) []slog.Attr {
-	if err == nil {
-		return nil
+	var layers []extendedErrFlat
+	for err != nil {
+		if ee, ok := err.(extendedErrFlat); ok {
+			layers = append(layers, ee)
+			err = ee.innerError()
+			continue
+		}
+		// Transparent for fmt.Errorf %w wrappers and similar.
+		err = errors.Unwrap(err)
 	}
-	if ee, ok := err.(extendedErrFlat); ok {
-		inner := collectDetails(ee.innerError())
-		return append(inner, ee.flatLogAttrs()...)
+
+	outer := -1
+	var s shadower
+	for i, ee := range layers {
+		var ok bool
+		if s, ok = ee.payload().(shadower); ok {
+			outer = i
+			break
+		}
 	}
-	// Transparent for fmt.Errorf %w wrappers and similar.
-	if u := errors.Unwrap(err); u != nil {
-		return collectDetails(u)
+
+	var attrs []slog.Attr
+	shadowed := false
+	for i, ee := range slices.Backward(layers) {
+		switch {
+		case outer < 0 || i < outer, i > outer && !s.Shadows(ee.payload()):
+			attrs = append(attrs, ee.flatLogAttrs()...)
+		case !shadowed:
+			attrs = append(attrs, layers[outer].flatLogAttrs()...)
+			shadowed = true
+		}
 	}
-	return nil
+	return attrs
 }
 
 // Extend wraps err with the given data, returning an [ExtendedError].
@@ -95,6 +187,21 @@ func Extend[T any](data T, err error) error {
 	return ExtendedError[T]{Data: data, err: err}
 }
 
+// ExtendMsg is like [Extend], but also prefixes the error message with the
+// message formatted from format and args, in the same "prefix: inner" form as
+// [fmt.Errorf]. Both are added in a single layer:
+//
+//	return xerrors.ExtendMsg(query, err, "loading user %d", id)
+//
+// The %w verb is not supported; the wrapped error is always err.
+// If err is nil, it returns nil.
+func ExtendMsg[T any](data T, err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return ExtendedError[T]{Data: data, err: err, msg: fmt.Sprintf(format, args...)}
+}
+
 // Extract walks the error chain and returns the Data field from the first
 // [ExtendedError] whose type parameter matches T. If no match is found,
 // it returns the zero value of T and false.
@@ -12,6 +12,7 @@ import (
 	"github.com/wood-jp/xerrors"
 	"github.com/wood-jp/xerrors/errclass"
 	"github.com/wood-jp/xerrors/errcontext"
+	"github.com/wood-jp/xerrors/redact"
 	"github.com/wood-jp/xerrors/stacktrace"
 )
 
@@ -318,3 +319,364 @@ func TestExtendedErrorLogValue(t *testing.T) {
 		t.Errorf("expected data value in output: %s", out)
 	}
 }
+
+// groupData logs as a group under the "group" key, holding a single "n" attr.
+type groupData struct{ N int }
+
+func (d groupData) LogValue() slog.Value {
+	return slog.GroupValue(slog.Attr{Key: "group", Value: slog.GroupValue(slog.Int("n", d.N))})
+}
+
+// shadowingData is like groupData, but supersedes inner shadowingData and
+// shadowedData payloads.
+type shadowingData struct{ N int }
+
+func (d shadowingData) LogValue() slog.Value {
+	return groupData(d).LogValue()
+}
+
+func (d shadowingData) Shadows(data any) bool {
+	switch data.(type) {
+	case shadowingData, shadowedData:
+		return true
+	}
+	return false
+}
+
+// shadowedData is like groupData, but is superseded by outer shadowingData payloads.
+type shadowedData struct{ N int }
+
+func (d shadowedData) LogValue() slog.Value {
+	return groupData(d).LogValue()
+}
+
+// detailKeys returns the keys of the 'error_detail' group logged for err.
+func detailKeys(t *testing.T, err error) ([]string, []slog.Attr) {
+	t.Helper()
+	detail, ok := findAttr(xerrors.Log(err).Value.Group(), "error_detail")
+	if !ok {
+		t.Fatal("missing 'error_detail' attr")
+	}
+	attrs := detail.Value.Group()
+	keys := make([]string, len(attrs))
+	for i, a := range attrs {
+		keys[i] = a.Key
+	}
+	return keys, attrs
+}
+
+// TestLogValueKeepsGroups validates that groups logged under the same key by
+// several ordinary payloads are all kept.
+func TestLogValueKeepsGroups(t *testing.T) {
+	t.Parallel()
+
+	err := xerrors.Extend(groupData{N: 1}, errors.New("oops"))
+	err = xerrors.Extend(groupData{N: 2}, err)
+
+	keys, attrs := detailKeys(t, err)
+	if want := "group group"; strings.Join(keys, " ") != want {
+		t.Fatalf("'error_detail' keys = %v, want %v", keys, want)
+	}
+	if got := attrs[0].Value.Group()[0].Value.Int64(); got != 1 {
+		t.Errorf("inner group n = %d, want 1", got)
+	}
+}
+
+// TestLogValueShadowsInner validates that when several shadowing payloads are
+// logged, only the outermost is kept, in the position of the innermost payload
+// it shadows.
+func TestLogValueShadowsInner(t *testing.T) {
+	t.Parallel()
+
+	err := xerrors.Extend(shadowedData{N: 0}, errors.New("oops"))
+	err = xerrors.Extend(shadowingData{N: 1}, err)
+	err = errclass.WrapAs(err, errclass.Transient)
+	err = xerrors.Extend(groupData{N: 3}, err)
+	err = xerrors.Extend(shadowingData{N: 2}, err)
+	err = errclass.WrapAs(err, errclass.Persistent)
+
+	keys, attrs := detailKeys(t, err)
+	// Other payloads, including groups with the same key, are never shadowed.
+	if want := "group class group class"; strings.Join(keys, " ") != want {
+		t.Fatalf("'error_detail' keys = %v, want %v", keys, want)
+	}
+	if got := attrs[0].Value.Group()[0].Value.Int64(); got != 2 {
+		t.Errorf("shadowing group n = %d, want 2", got)
+	}
+	if got := attrs[2].Value.Group()[0].Value.Int64(); got != 3 {
+		t.Errorf("ordinary group n = %d, want 3", got)
+	}
+}
+
+// TestLogValueShadowsOnlyInner validates that payloads outside the outermost
+// shadowing payload are logged, even if it would shadow them.
+func TestLogValueShadowsOnlyInner(t *testing.T) {
+	t.Parallel()
+
+	err := xerrors.Extend(shadowingData{N: 1}, errors.New("oops"))
+	err = xerrors.Extend(shadowedData{N: 2}, err)
+
+	keys, attrs := detailKeys(t, err)
+	if want := "group group"; strings.Join(keys, " ") != want {
+		t.Fatalf("'error_detail' keys = %v, want %v", keys, want)
+	}
+	if got := attrs[1].Value.Group()[0].Value.Int64(); got != 2 {
+		t.Errorf("outer group n = %d, want 2", got)
+	}
+}
+
+// account is a payload that knows how to redact itself.
+type account struct {
+	Email string
+	Plan  string
+}
+
+func (a account) LogValue() slog.Value {
+	return slog.GroupValue(slog.Group("account", slog.String("email", a.Email), slog.String("plan", a.Plan)))
+}
+
+func (a account) Redact() any {
+	return account{Email: "a***@example.com", Plan: a.Plan}
+}
+
+func TestLogValueRedaction(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	old := redact.GetPolicy()
+	t.Cleanup(func() { redact.SetPolicy(old) })
+
+	err := xerrors.Extend(account{Email: "alice@example.com", Plan: "pro"}, errors.New("oops"))
+	err = errcontext.Add(err, slog.String("api_token", "s3cr3t"), slog.String("user", "alice"))
+
+	render := func() string {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Info("test", xerrors.Log(err))
+		return buf.String()
+	}
+
+	redact.SetPolicy(redact.Policy{Keys: []string{"*token*"}})
+	out := render()
+	for _, want := range []string{`"account":{"email":"a***@example.com","plan":"pro"}`, `"api_token":"[REDACTED]"`, `"user":"alice"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output: %s", want, out)
+		}
+	}
+	for _, deny := range []string{"alice@example.com", "s3cr3t"} {
+		if strings.Contains(out, deny) {
+			t.Errorf("unexpected %s in output: %s", deny, out)
+		}
+	}
+
+	redact.SetPolicy(redact.Policy{Mode: redact.ModeReveal, Keys: []string{"*token*"}})
+	out = render()
+	for _, want := range []string{"alice@example.com", "s3cr3t"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output: %s", want, out)
+		}
+	}
+}
+
+func TestNewSentinel(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := xerrors.NewSentinel("not found",
+		xerrors.With(errclass.Persistent),
+		errcontext.With(slog.String("table", "users")),
+	)
+	errOther := xerrors.NewSentinel("not found", xerrors.With(errclass.Persistent))
+
+	tests := []struct {
+		name      string
+		err       error
+		wantClass errclass.Class
+	}{
+		{"bare", errNotFound, errclass.Persistent},
+		{"fmt wrapped", fmt.Errorf("loading user: %w", errNotFound), errclass.Persistent},
+		{"extended", errcontext.Add(errNotFound, slog.Int("user_id", 42)), errclass.Persistent},
+		{"outer class wins", errclass.WrapAs(errNotFound, errclass.Transient), errclass.Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if !errors.Is(tt.err, errNotFound) {
+				t.Error("expected errors.Is to match the sentinel")
+			}
+			if errors.Is(tt.err, errOther) {
+				t.Error("expected errors.Is not to match a different sentinel with the same message")
+			}
+			if got := errclass.GetClass(tt.err); got != tt.wantClass {
+				t.Errorf("GetClass() = %v, want %v", got, tt.wantClass)
+			}
+			if got := errcontext.Get(tt.err)["table"].String(); got != "users" {
+				t.Errorf("context table = %q, want users", got)
+			}
+
+			var buf bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(tt.err))
+			if want := `"class":"` + tt.wantClass.String() + `"`; !strings.Contains(buf.String(), want) {
+				t.Errorf("expected %s in %s", want, buf.String())
+			}
+			if want := `"table":"users"`; !strings.Contains(buf.String(), want) {
+				t.Errorf("expected %s in %s", want, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewSentinelWithoutPayloads(t *testing.T) {
+	t.Parallel()
+
+	err := xerrors.NewSentinel("plain")
+	if err.Error() != "plain" {
+		t.Errorf("Error() = %q, want plain", err.Error())
+	}
+	if errors.Unwrap(err) != nil {
+		t.Error("expected nothing to unwrap")
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", slog.Any("error", err))
+	if want := `"error":{"error":"plain"}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in %s", want, buf.String())
+	}
+}
+
+func TestExtendMsg(t *testing.T) {
+	t.Parallel()
+
+	type query struct{ Table string }
+
+	if err := xerrors.ExtendMsg(query{}, nil, "loading user %d", 42); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	err := xerrors.ExtendMsg(query{Table: "users"}, errTest, "loading user %d", 42)
+	if want := "loading user 42: " + errTest.Error(); err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, errTest) {
+		t.Error("expected errors.Is to match the inner error")
+	}
+	if errors.Unwrap(err) != errTest { //nolint:errorlint // intentional identity check: ExtendMsg must wrap err itself
+		t.Error("expected a single layer")
+	}
+	if got, ok := xerrors.Extract[query](err); !ok || got.Table != "users" {
+		t.Errorf("Extract() = %v, %v", got, ok)
+	}
+
+	// Prefixes nest in the same order as fmt.Errorf.
+	outer := xerrors.ExtendMsg(1, err, "handling request")
+	if want := "handling request: loading user 42: " + errTest.Error(); outer.Error() != want {
+		t.Errorf("Error() = %q, want %q", outer.Error(), want)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(outer))
+	if want := `"error":"handling request: loading user 42: ` + errTest.Error() + `"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in %s", want, buf.String())
+	}
+}
+
+// failAt returns an error with a stack trace captured in failAt, so that errors
+// from different calls share their frames.
+func failAt(msg string) error {
+	return stacktrace.Wrap(errors.New(msg))
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	base := xerrors.Fingerprint(errclass.WrapAs(failAt("user 42 not found"), errclass.Persistent))
+	if len(base) != 16 {
+		t.Fatalf("expected 16 hex characters, got %q", base)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		same bool
+	}{
+		{"different number", errclass.WrapAs(failAt("user 7 not found"), errclass.Persistent), true},
+		{"different class", errclass.WrapAs(failAt("user 42 not found"), errclass.Transient), false},
+		{"outer class wins", errclass.WrapAs(errclass.WrapAs(failAt("user 42 not found"), errclass.Transient), errclass.Persistent), true},
+		{"different message", errclass.WrapAs(failAt("user 42 deleted"), errclass.Persistent), false},
+		{"no stack trace", errclass.WrapAs(errors.New("user 42 not found"), errclass.Persistent), false},
+		{"payloads without components", errcontext.Add(errclass.WrapAs(failAt("user 42 not found"), errclass.Persistent), slog.Int("user_id", 42)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := xerrors.Fingerprint(tt.err); (got == base) != tt.same {
+				t.Errorf("Fingerprint() = %q, base %q, want same=%v", got, base, tt.same)
+			}
+		})
+	}
+
+	if got := xerrors.Fingerprint(nil); got != "" {
+		t.Errorf("Fingerprint(nil) = %q, want empty", got)
+	}
+}
+
+func TestFingerprintNormalisesMessage(t *testing.T) {
+	t.Parallel()
+
+	pairs := [][2]string{
+		{"timeout after 1.5s", "timeout after 30s"},
+		{"order 3fa85f64-5717-4562-b3fc-2c963f66afa6 failed", "order 16fd2706-8baf-433b-82eb-8c7fada847da failed"},
+		{"bad pointer 0x1f", "bad pointer 0xc000012345"},
+		{"commit deadbeef12 missing", "commit 0123abcdef missing"},
+	}
+	for _, p := range pairs {
+		if a, b := xerrors.Fingerprint(errors.New(p[0])), xerrors.Fingerprint(errors.New(p[1])); a != b {
+			t.Errorf("expected %q and %q to share a fingerprint", p[0], p[1])
+		}
+	}
+}
+
+func TestFingerprintOptions(t *testing.T) {
+	t.Parallel()
+
+	a := errclass.WrapAs(failAt("user 42 not found"), errclass.Persistent)
+	b := errclass.WrapAs(errors.New("user deleted"), errclass.Persistent)
+	c := errclass.WrapAs(failAt("user 42 not found"), errclass.Transient)
+
+	if xerrors.Fingerprint(a, xerrors.FingerprintWithout(xerrors.FingerprintMessage, "frames")) !=
+		xerrors.Fingerprint(b, xerrors.FingerprintWithout(xerrors.FingerprintMessage, "frames")) {
+		t.Error("expected errors of the same class to match when only the class is included")
+	}
+	if xerrors.Fingerprint(a, xerrors.FingerprintWithout("class")) != xerrors.Fingerprint(c, xerrors.FingerprintWithout("class")) {
+		t.Error("expected errors differing only by class to match when the class is excluded")
+	}
+
+	// With a single frame only the innermost function counts, so calls from different callers match.
+	fromHere := failAt("x")
+	fromClosure := func() error { return failAt("x") }()
+	if xerrors.Fingerprint(fromHere) == xerrors.Fingerprint(fromClosure) {
+		t.Error("expected different callers to differ with the default frame limit")
+	}
+	if xerrors.Fingerprint(fromHere, xerrors.FingerprintMaxValues(1)) != xerrors.Fingerprint(fromClosure, xerrors.FingerprintMaxValues(1)) {
+		t.Error("expected different callers to match with a single frame")
+	}
+}
+
+func TestLogFingerprint(t *testing.T) { //nolint:paralleltest // test uses package-level variable
+	err := errclass.WrapAs(errTest, errclass.Persistent)
+	log := func() string {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", xerrors.Log(err))
+		return buf.String()
+	}
+
+	if strings.Contains(log(), "fingerprint") {
+		t.Error("expected no fingerprint by default")
+	}
+
+	xerrors.SetLogFingerprint(true, xerrors.FingerprintWithout("class"))
+	t.Cleanup(func() { xerrors.SetLogFingerprint(false) })
+	want := `"error":{"error":"this is a test error","fingerprint":"` + xerrors.Fingerprint(err, xerrors.FingerprintWithout("class")) + `",`
+	if out := log(); !strings.Contains(out, want) {
+		t.Errorf("expected %s in %s", want, out)
+	}
+
+	xerrors.SetLogFingerprint(false)
+	if strings.Contains(log(), "fingerprint") {
+		t.Error("expected no fingerprint once disabled")
+	}
+}